@@ -5,6 +5,7 @@
 package dbx
 
 import (
+	"database/sql/driver"
 	"errors"
 	"fmt"
 	"sort"
@@ -104,6 +105,63 @@ type Builder interface {
 	DropIndex(table, name string) *Query
 }
 
+// The following interfaces may be implemented by a Builder to support the query features whose SQL differs
+// among databases. If a builder does not implement one of them, the standard SQL is generated for the feature,
+// or an error is returned when the query is built if the feature has no standard SQL.
+type (
+	// GroupingBuilder builds the ROLLUP, CUBE and GROUPING SETS constructs and the GROUPING function calls.
+	GroupingBuilder interface {
+		// BuildGrouping generates a GROUP BY clause from the given group-by columns followed by
+		// a ROLLUP, CUBE or GROUPING SETS construct. An error is returned if the construct is not supported.
+		BuildGrouping(cols []string, grouping *GroupingInfo) (string, error)
+		// BuildGroupingFunc generates a function call telling whether the given columns are aggregated
+		// in a super-aggregate row.
+		BuildGroupingFunc(cols []string) string
+	}
+
+	// ExistsBuilder builds the statements used by SelectQuery.Exists.
+	ExistsBuilder interface {
+		// BuildExists generates a SELECT statement that checks whether the given SELECT statement returns any row.
+		BuildExists(sql string) string
+	}
+
+	// DistinctOnBuilder builds the DISTINCT ON option of SELECT statements.
+	DistinctOnBuilder interface {
+		// BuildDistinctOn generates a DISTINCT ON option for the SELECT clause from the given columns.
+		// An error is returned if DISTINCT ON is not supported.
+		BuildDistinctOn(cols []string) (string, error)
+	}
+
+	// IndexHintBuilder builds the index hints of the tables in the FROM and JOIN clauses.
+	IndexHintBuilder interface {
+		// BuildIndexHint generates an index hint following a table in the FROM or JOIN clause.
+		// An error is returned if the index hint is not supported.
+		BuildIndexHint(hint IndexHint) (string, error)
+	}
+
+	// JoinTypeBuilder builds the join operators, such as lateral joins.
+	JoinTypeBuilder interface {
+		// BuildJoinType generates the join operator used by the given JOIN clause.
+		// An error is returned if the join is not supported.
+		BuildJoinType(join JoinInfo) (string, error)
+	}
+
+	// SequenceBuilder builds the expressions taking values from sequences.
+	SequenceBuilder interface {
+		// BuildSequenceValue generates an expression that takes the next value from the named sequence.
+		// An error is returned if sequences are not supported.
+		BuildSequenceValue(name string) (string, error)
+	}
+
+	// ReturningBuilder executes the INSERT statements returning the primary key values generated by the database.
+	ReturningBuilder interface {
+		// InsertReturningPK executes the INSERT query and populates dest with the value of the named primary key
+		// column generated by the database. The autoInc parameter indicates whether the column is auto-incremental.
+		// It returns false without executing the query if the value can only be obtained by Result.LastInsertId.
+		InsertReturningPK(query *Query, pkName string, autoInc bool, dest interface{}) (bool, error)
+	}
+)
+
 // BaseBuilder provides a basic implementation of the Builder interface.
 type BaseBuilder struct {
 	db       *DB
@@ -132,6 +190,23 @@ func (b *BaseBuilder) NewQuery(sql string) *Query {
 	return NewQuery(b.db, b.executor, sql)
 }
 
+// queryReturning executes a statement returning a single row, which is derived from the given query and uses
+// the same parameters and context, and populates the row into dest.
+func (b *BaseBuilder) queryReturning(query *Query, sql string, dest ...interface{}) error {
+	return b.NewQuery(sql).Bind(query.Params()).WithContext(query.Context()).Row(dest...)
+}
+
+// buildQuery ends building the statement started by calling beginBuild with params and creates a Query
+// for it. The error occurring when the expressions of the statement were built becomes the LastError
+// of the query.
+func (b *BaseBuilder) buildQuery(sql string, params Params) *Query {
+	q := b.NewQuery(sql).Bind(params)
+	if err := b.db.endBuild(params); err != nil {
+		q.LastError = err
+	}
+	return q
+}
+
 // GeneratePlaceholder generates an anonymous parameter placeholder with the given parameter ID.
 func (b *BaseBuilder) GeneratePlaceholder(int) string {
 	return "?"
@@ -171,6 +246,7 @@ func (b *BaseBuilder) Insert(table string, cols Params) *Query {
 	sort.Strings(names)
 
 	params := Params{}
+	b.db.beginBuild(params)
 	columns := make([]string, 0, len(names))
 	values := make([]string, 0, len(names))
 	for _, name := range names {
@@ -195,7 +271,7 @@ func (b *BaseBuilder) Insert(table string, cols Params) *Query {
 		)
 	}
 
-	return b.NewQuery(sql).Bind(params)
+	return b.buildQuery(sql, params)
 }
 
 // Upsert creates a Query that represents an UPSERT SQL statement.
@@ -209,6 +285,34 @@ func (b *BaseBuilder) Upsert(table string, cols Params, constraints ...string) *
 	return q
 }
 
+// insertOnly wraps a column value passed to Builder.Upsert which is inserted into a new row
+// but is not updated when the row already exists.
+type insertOnly struct {
+	value interface{}
+}
+
+// Value implements driver.Valuer so that the builders unaware of insertOnly use the wrapped value as is.
+func (v insertOnly) Value() (driver.Value, error) {
+	return driver.DefaultParameterConverter.ConvertValue(v.value)
+}
+
+// upsertColumns returns the columns to be inserted by an UPSERT statement with the insertOnly values unwrapped,
+// and the names of the columns to be updated in sorted order.
+func upsertColumns(cols Params) (Params, []string) {
+	inserted := make(Params, len(cols))
+	updated := make([]string, 0, len(cols))
+	for name, value := range cols {
+		if v, ok := value.(insertOnly); ok {
+			inserted[name] = v.value
+			continue
+		}
+		inserted[name] = value
+		updated = append(updated, name)
+	}
+	sort.Strings(updated)
+	return inserted, updated
+}
+
 // Update creates a Query that represents an UPDATE SQL statement.
 // The keys of cols are the column names, while the values of cols are the corresponding new column
 // values. If the "where" expression is nil, the UPDATE SQL statement will have no WHERE clause
@@ -221,6 +325,7 @@ func (b *BaseBuilder) Update(table string, cols Params, where Expression) *Query
 	sort.Strings(names)
 
 	params := Params{}
+	b.db.beginBuild(params)
 	lines := make([]string, 0, len(names))
 	for _, name := range names {
 		value := cols[name]
@@ -241,7 +346,7 @@ func (b *BaseBuilder) Update(table string, cols Params, where Expression) *Query
 		}
 	}
 
-	return b.NewQuery(sql).Bind(params)
+	return b.buildQuery(sql, params)
 }
 
 // Delete creates a Query that represents a DELETE SQL statement.
@@ -250,13 +355,14 @@ func (b *BaseBuilder) Update(table string, cols Params, where Expression) *Query
 func (b *BaseBuilder) Delete(table string, where Expression) *Query {
 	sql := "DELETE FROM " + b.db.QuoteTableName(table)
 	params := Params{}
+	b.db.beginBuild(params)
 	if where != nil {
 		w := where.Build(b.db, params)
 		if w != "" {
 			sql += " WHERE " + w
 		}
 	}
-	return b.NewQuery(sql).Bind(params)
+	return b.buildQuery(sql, params)
 }
 
 // CreateTable creates a Query that represents a CREATE TABLE SQL statement.
@@ -388,6 +494,15 @@ func (b *BaseBuilder) DropIndex(table, name string) *Query {
 	return b.NewQuery(sql)
 }
 
+// quoteIndexes quotes a list of index names and concatenates them with commas.
+func (b *BaseBuilder) quoteIndexes(indexes []string) string {
+	quoted := make([]string, len(indexes))
+	for i, index := range indexes {
+		quoted[i] = b.db.QuoteSimpleColumnName(index)
+	}
+	return strings.Join(quoted, ", ")
+}
+
 // quoteColumns quotes a list of columns and concatenates them with commas.
 func (b *BaseBuilder) quoteColumns(cols []string) string {
 	s := ""
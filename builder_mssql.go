@@ -5,6 +5,7 @@
 package dbx
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
@@ -15,7 +16,15 @@ type MssqlBuilder struct {
 	qb *MssqlQueryBuilder
 }
 
-var _ Builder = &MssqlBuilder{}
+var (
+	_ Builder          = &MssqlBuilder{}
+	_ ExistsBuilder    = &MssqlBuilder{}
+	_ GroupingBuilder  = &MssqlBuilder{}
+	_ IndexHintBuilder = &MssqlBuilder{}
+	_ JoinTypeBuilder  = &MssqlBuilder{}
+	_ ReturningBuilder = &MssqlBuilder{}
+	_ SequenceBuilder  = &MssqlBuilder{}
+)
 
 // MssqlQueryBuilder is the query builder for SQL Server databases.
 type MssqlQueryBuilder struct {
@@ -113,3 +122,70 @@ func (q *MssqlQueryBuilder) BuildOrderByAndLimit(sql string, cols []string, limi
 	}
 	return sql
 }
+
+// BuildExists generates a SELECT statement that checks whether the given SELECT statement returns any row.
+func (b *MssqlBuilder) BuildExists(sql string) string {
+	return "SELECT CASE WHEN EXISTS(" + sql + ") THEN 1 ELSE 0 END"
+}
+
+// BuildGrouping generates a GROUP BY clause from the given group-by columns and grouping construct.
+func (b *MssqlBuilder) BuildGrouping(cols []string, grouping *GroupingInfo) (string, error) {
+	return buildStandardGrouping(b, b.db, cols, grouping)
+}
+
+// BuildGroupingFunc generates a GROUPING function call over the given columns.
+// SQL Server only accepts a single column in GROUPING, so GROUPING_ID is called for multiple columns.
+func (b *MssqlBuilder) BuildGroupingFunc(cols []string) string {
+	if len(cols) > 1 {
+		return buildFuncCall(b.db, "GROUPING_ID", cols)
+	}
+	return buildFuncCall(b.db, "GROUPING", cols)
+}
+
+// BuildIndexHint generates an index hint following a table in the FROM or JOIN clause.
+// Both USE and FORCE hints are generated as an INDEX table hint, while IGNORE is not supported.
+func (b *MssqlBuilder) BuildIndexHint(hint IndexHint) (string, error) {
+	if hint.Type == "IGNORE" {
+		return "", errors.New("SQL Server does not support ignoring indexes")
+	}
+	return "WITH (INDEX(" + b.quoteIndexes(hint.Indexes) + "))", nil
+}
+
+// BuildJoinType generates the join operator used by the given JOIN clause.
+// Lateral joins are generated as CROSS APPLY and OUTER APPLY, while NATURAL JOIN and JOIN USING are not supported.
+func (b *MssqlBuilder) BuildJoinType(join JoinInfo) (string, error) {
+	if join.Join == "NATURAL JOIN" {
+		return "", errors.New("SQL Server does not support NATURAL JOIN")
+	}
+	if _, ok := join.On.(*usingExp); ok {
+		return "", errors.New("SQL Server does not support JOIN USING")
+	}
+	return buildApplyJoinType(join)
+}
+
+// BuildSequenceValue generates an expression that takes the next value from the named sequence.
+func (b *MssqlBuilder) BuildSequenceValue(name string) (string, error) {
+	return "NEXT VALUE FOR " + b.db.QuoteTableName(name), nil
+}
+
+// InsertReturningPK executes the INSERT query and populates dest with the value of the named primary key
+// column generated by the database. The identity columns are read by SCOPE_IDENTITY, while the other
+// columns are returned by an OUTPUT clause.
+func (b *MssqlBuilder) InsertReturningPK(query *Query, pkName string, autoInc bool, dest interface{}) (bool, error) {
+	if autoInc {
+		return true, b.queryReturning(query, query.SQL()+"; SELECT CAST(SCOPE_IDENTITY() AS BIGINT)", dest)
+	}
+	return true, b.queryReturning(query, insertOutput(query.SQL(), "OUTPUT INSERTED."+b.db.QuoteColumnName(pkName)), dest)
+}
+
+// insertOutput inserts an OUTPUT clause into an INSERT statement before its VALUES clause.
+func insertOutput(sql, output string) string {
+	i := strings.Index(sql, " VALUES (")
+	if i < 0 {
+		i = strings.Index(sql, " DEFAULT VALUES")
+	}
+	if i < 0 {
+		return sql + " " + output
+	}
+	return sql[:i] + " " + output + sql[i:]
+}
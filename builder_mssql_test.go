@@ -5,7 +5,10 @@
 package dbx
 
 import (
+	"context"
+	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -65,6 +68,86 @@ func TestMssqlQueryBuilder_BuildOrderByAndLimit(t *testing.T) {
 	assert.Equal(t, sql, expected, "t4")
 }
 
+func TestMssqlBuilder_BuildExists(t *testing.T) {
+	b := getMssqlBuilder().(*MssqlBuilder)
+	sql := b.BuildExists("SELECT * FROM users")
+	assert.Equal(t, "SELECT CASE WHEN EXISTS(SELECT * FROM users) THEN 1 ELSE 0 END", sql)
+}
+
+func TestMssqlBuilder_BuildGrouping(t *testing.T) {
+	b := getMssqlBuilder().(*MssqlBuilder)
+
+	sql, err := b.BuildGrouping([]string{"region"}, &GroupingInfo{"CUBE", [][]string{{"year", "month"}}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "GROUP BY [region], CUBE ([year], [month])", sql)
+	}
+	assert.Equal(t, "GROUPING([year])", b.BuildGroupingFunc([]string{"year"}))
+	assert.Equal(t, "GROUPING_ID([year], [month])", b.BuildGroupingFunc([]string{"year", "month"}))
+}
+
+func TestMssqlBuilder_BuildIndexHint(t *testing.T) {
+	b := getMssqlBuilder().(*MssqlBuilder)
+
+	sql, err := b.BuildIndexHint(IndexHint{"users", "USE", []string{"idx_name", "idx_email"}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "WITH (INDEX([idx_name], [idx_email]))", sql)
+	}
+	_, err = b.BuildIndexHint(IndexHint{"users", "IGNORE", []string{"idx_name"}})
+	assert.NotNil(t, err)
+}
+
+func TestMssqlBuilder_BuildJoinType(t *testing.T) {
+	b := getMssqlBuilder().(*MssqlBuilder)
+
+	typ, err := b.BuildJoinType(JoinInfo{"CROSS JOIN LATERAL", "users", nil})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "CROSS APPLY", typ)
+	}
+	typ, err = b.BuildJoinType(JoinInfo{"LEFT JOIN LATERAL", "users", nil})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "OUTER APPLY", typ)
+	}
+	_, err = b.BuildJoinType(JoinInfo{"LEFT JOIN LATERAL", "users", NewExp("1=1")})
+	assert.NotNil(t, err)
+	_, err = b.BuildJoinType(JoinInfo{"NATURAL JOIN", "users", nil})
+	assert.NotNil(t, err)
+	_, err = b.BuildJoinType(JoinInfo{"INNER JOIN", "users", &usingExp{[]string{"id"}}})
+	assert.NotNil(t, err)
+}
+
+func TestMssqlBuilder_BuildSequenceValue(t *testing.T) {
+	b := getMssqlBuilder().(*MssqlBuilder)
+	sql, err := b.BuildSequenceValue("users_seq")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "NEXT VALUE FOR [users_seq]", sql)
+	}
+}
+
+func TestMssqlBuilder_InsertReturningPK(t *testing.T) {
+	b := getMssqlBuilder().(*MssqlBuilder)
+	var queried string
+	b.db.QueryLogFunc = func(ctx context.Context, t time.Duration, s string, rows *sql.Rows, err error) {
+		queried = s
+	}
+
+	var id int
+	ok, _ := b.InsertReturningPK(b.Insert("users", Params{"name": "test"}), "id", true, &id)
+	assert.True(t, ok)
+	assert.Equal(t, "INSERT INTO [users] ([name]) VALUES ('test'); SELECT CAST(SCOPE_IDENTITY() AS BIGINT)", queried)
+
+	var uuid string
+	ok, _ = b.InsertReturningPK(b.Insert("users", Params{"name": "test"}), "id", false, &uuid)
+	assert.True(t, ok)
+	assert.Equal(t, "INSERT INTO [users] ([name]) OUTPUT INSERTED.[id] VALUES ('test')", queried)
+}
+
+func Test_insertOutput(t *testing.T) {
+	assert.Equal(t, "INSERT INTO [users] ([name]) OUTPUT INSERTED.[id] VALUES ({:p0})",
+		insertOutput("INSERT INTO [users] ([name]) VALUES ({:p0})", "OUTPUT INSERTED.[id]"))
+	assert.Equal(t, "INSERT INTO [users] OUTPUT INSERTED.[id] DEFAULT VALUES",
+		insertOutput("INSERT INTO [users] DEFAULT VALUES", "OUTPUT INSERTED.[id]"))
+}
+
 func getMssqlBuilder() Builder {
 	db := getDB()
 	b := NewMssqlBuilder(db, db.sqlDB)
@@ -5,9 +5,9 @@
 package dbx
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
-	"sort"
 	"strings"
 )
 
@@ -17,7 +17,13 @@ type MysqlBuilder struct {
 	qb *BaseQueryBuilder
 }
 
-var _ Builder = &MysqlBuilder{}
+var (
+	_ Builder          = &MysqlBuilder{}
+	_ SyntaxBuilder    = &MysqlBuilder{}
+	_ GroupingBuilder  = &MysqlBuilder{}
+	_ IndexHintBuilder = &MysqlBuilder{}
+	_ JoinTypeBuilder  = &MysqlBuilder{}
+)
 
 // NewMysqlBuilder creates a new MysqlBuilder instance.
 func NewMysqlBuilder(db *DB, executor Executor) Builder {
@@ -27,6 +33,12 @@ func NewMysqlBuilder(db *DB, executor Executor) Builder {
 	}
 }
 
+// SQLSyntax returns the lexical rules of the SQL dialect.
+// MySQL strings support backslash escapes, "#" starts a comment, and so does "--" followed by a whitespace.
+func (b *MysqlBuilder) SQLSyntax() SQLSyntax {
+	return SQLSyntax{BackslashEscapes: true, HashComments: true, SpacedDashComments: true}
+}
+
 // QueryBuilder returns the query builder supporting the current DB.
 func (b *MysqlBuilder) QueryBuilder() QueryBuilder {
 	return b.qb
@@ -69,13 +81,9 @@ func (b *MysqlBuilder) QuoteSimpleColumnName(s string) string {
 // The keys of cols are the column names, while the values of cols are the corresponding column
 // values to be inserted.
 func (b *MysqlBuilder) Upsert(table string, cols Params, constraints ...string) *Query {
+	cols, names := upsertColumns(cols)
 	q := b.Insert(table, cols)
-
-	names := []string{}
-	for name := range cols {
-		names = append(names, name)
-	}
-	sort.Strings(names)
+	b.db.beginBuild(q.params)
 
 	lines := []string{}
 	for _, name := range names {
@@ -91,7 +99,11 @@ func (b *MysqlBuilder) Upsert(table string, cols Params, constraints ...string)
 
 	q.sql += " ON DUPLICATE KEY UPDATE " + strings.Join(lines, ", ")
 
-	return q
+	uq := b.buildQuery(q.sql, q.params)
+	if q.LastError != nil {
+		uq.LastError = q.LastError
+	}
+	return uq
 }
 
 var mysqlColumnRegexp = regexp.MustCompile("(?m)^\\s*[`\"](.*?)[`\"]\\s+(.*?),?$")
@@ -131,3 +143,37 @@ func (b *MysqlBuilder) DropForeignKey(table, name string) *Query {
 	sql := fmt.Sprintf("ALTER TABLE %v DROP FOREIGN KEY %v", b.db.QuoteTableName(table), b.db.QuoteColumnName(name))
 	return b.db.NewQuery(sql)
 }
+
+// BuildGrouping generates a GROUP BY clause from the given group-by columns and grouping construct.
+// MySQL only supports ROLLUP which cannot be combined with other group-by columns.
+func (b *MysqlBuilder) BuildGrouping(cols []string, grouping *GroupingInfo) (string, error) {
+	if grouping.Type != "ROLLUP" {
+		return "", errors.New("MySQL does not support " + grouping.Type)
+	}
+	if err := grouping.validate(); err != nil {
+		return "", err
+	}
+	if len(cols) > 0 {
+		return "", errors.New("MySQL does not support combining ROLLUP with other GROUP BY columns")
+	}
+	return b.qb.BuildGroupBy(grouping.Sets[0]) + " WITH ROLLUP", nil
+}
+
+// BuildGroupingFunc generates a GROUPING function call over the given columns.
+func (b *MysqlBuilder) BuildGroupingFunc(cols []string) string {
+	return buildFuncCall(b.db, "GROUPING", cols)
+}
+
+// BuildIndexHint generates an index hint following a table in the FROM or JOIN clause.
+func (b *MysqlBuilder) BuildIndexHint(hint IndexHint) (string, error) {
+	return hint.Type + " INDEX (" + b.quoteIndexes(hint.Indexes) + ")", nil
+}
+
+// BuildJoinType generates the join operator used by the given JOIN clause.
+// MySQL does not support FULL JOIN.
+func (b *MysqlBuilder) BuildJoinType(join JoinInfo) (string, error) {
+	if join.Join == "FULL JOIN" {
+		return "", errors.New("MySQL does not support FULL JOIN")
+	}
+	return join.Join, nil
+}
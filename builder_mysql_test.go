@@ -41,6 +41,18 @@ func TestMysqlBuilder_Upsert(t *testing.T) {
 	assert.Equal(t, q.Params()["p3"], "James", "t3")
 }
 
+func TestMysqlBuilder_UpsertRawSQL(t *testing.T) {
+	b := getMysqlBuilder()
+	q := b.Upsert("users", Params{
+		"name":    "James",
+		"created": insertOnly{1},
+		"visits":  NewExp("visits+1"),
+	})
+	assert.Equal(t, "INSERT INTO `users` (`created`, `name`, `visits`) VALUES (?, ?, visits+1) ON DUPLICATE KEY UPDATE `name`=?, `visits`=visits+1", q.rawSQL)
+	assert.Equal(t, []string{"p0", "p1", "p2"}, q.placeholders)
+	assert.Equal(t, Params{"p0": 1, "p1": "James", "p2": "James"}, q.Params())
+}
+
 func TestMysqlBuilder_RenameColumn(t *testing.T) {
 	b := getMysqlBuilder()
 	q := b.RenameColumn("users", "name", "username")
@@ -61,6 +73,55 @@ func TestMysqlBuilder_DropForeignKey(t *testing.T) {
 	assert.Equal(t, q.SQL(), "ALTER TABLE `users` DROP FOREIGN KEY `fk`", "t1")
 }
 
+func TestMysqlBuilder_BuildGrouping(t *testing.T) {
+	b := getMysqlBuilder().(*MysqlBuilder)
+
+	sql, err := b.BuildGrouping(nil, &GroupingInfo{"ROLLUP", [][]string{{"year", "month"}}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "GROUP BY `year`, `month` WITH ROLLUP", sql)
+	}
+	_, err = b.BuildGrouping([]string{"region"}, &GroupingInfo{"ROLLUP", [][]string{{"year"}}})
+	assert.NotNil(t, err)
+	_, err = b.BuildGrouping(nil, &GroupingInfo{"CUBE", [][]string{{"year"}}})
+	assert.NotNil(t, err)
+	_, err = b.BuildGrouping(nil, &GroupingInfo{"ROLLUP", [][]string{{}}})
+	assert.NotNil(t, err)
+	_, err = b.BuildGrouping(nil, &GroupingInfo{"ROLLUP", nil})
+	assert.NotNil(t, err)
+}
+
+func TestMysqlBuilder_BuildIndexHint(t *testing.T) {
+	b := getMysqlBuilder().(*MysqlBuilder)
+
+	sql, err := b.BuildIndexHint(IndexHint{"users", "FORCE", []string{"idx_name", "idx_email"}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "FORCE INDEX (`idx_name`, `idx_email`)", sql)
+	}
+}
+
+func TestMysqlBuilder_BuildDistinctOn(t *testing.T) {
+	b := getMysqlBuilder().(*MysqlBuilder)
+	_, err := buildDistinctOn(b, []string{"name"})
+	assert.NotNil(t, err)
+}
+
+func TestMysqlBuilder_BuildJoinType(t *testing.T) {
+	b := getMysqlBuilder().(*MysqlBuilder)
+
+	typ, err := b.BuildJoinType(JoinInfo{"CROSS JOIN LATERAL", "users", nil})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "CROSS JOIN LATERAL", typ)
+	}
+	_, err = b.BuildJoinType(JoinInfo{"FULL JOIN", "users", nil})
+	assert.NotNil(t, err)
+}
+
+func TestMysqlBuilder_NoSequenceSupport(t *testing.T) {
+	b := getMysqlBuilder().(*MysqlBuilder)
+	_, err := buildSequenceValue(b, "users_seq")
+	assert.NotNil(t, err)
+}
+
 func getMysqlBuilder() Builder {
 	db := getDB()
 	b := NewMysqlBuilder(db, db.sqlDB)
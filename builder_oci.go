@@ -5,6 +5,7 @@
 package dbx
 
 import (
+	"database/sql"
 	"fmt"
 )
 
@@ -14,7 +15,14 @@ type OciBuilder struct {
 	qb *OciQueryBuilder
 }
 
-var _ Builder = &OciBuilder{}
+var (
+	_ Builder          = &OciBuilder{}
+	_ ExistsBuilder    = &OciBuilder{}
+	_ GroupingBuilder  = &OciBuilder{}
+	_ JoinTypeBuilder  = &OciBuilder{}
+	_ ReturningBuilder = &OciBuilder{}
+	_ SequenceBuilder  = &OciBuilder{}
+)
 
 // OciQueryBuilder is the query builder for Oracle databases.
 type OciQueryBuilder struct {
@@ -96,3 +104,47 @@ func (q *OciQueryBuilder) BuildOrderByAndLimit(sql string, cols []string, limit
 	PAGINATION AS (SELECT USER_SQL.*, rownum as rowNumId FROM USER_SQL)
 SELECT * FROM PAGINATION WHERE ` + c
 }
+
+// BuildExists generates a SELECT statement that checks whether the given SELECT statement returns any row.
+func (b *OciBuilder) BuildExists(sql string) string {
+	return "SELECT CASE WHEN EXISTS(" + sql + ") THEN 1 ELSE 0 END FROM DUAL"
+}
+
+// BuildGrouping generates a GROUP BY clause from the given group-by columns and grouping construct.
+func (b *OciBuilder) BuildGrouping(cols []string, grouping *GroupingInfo) (string, error) {
+	return buildStandardGrouping(b, b.db, cols, grouping)
+}
+
+// BuildGroupingFunc generates a GROUPING function call over the given columns.
+// Oracle only accepts a single column in GROUPING, so GROUPING_ID is called for multiple columns.
+func (b *OciBuilder) BuildGroupingFunc(cols []string) string {
+	if len(cols) > 1 {
+		return buildFuncCall(b.db, "GROUPING_ID", cols)
+	}
+	return buildFuncCall(b.db, "GROUPING", cols)
+}
+
+// BuildJoinType generates the join operator used by the given JOIN clause.
+// Lateral joins are generated as CROSS APPLY and OUTER APPLY.
+func (b *OciBuilder) BuildJoinType(join JoinInfo) (string, error) {
+	return buildApplyJoinType(join)
+}
+
+// BuildSequenceValue generates an expression that takes the next value from the named sequence.
+func (b *OciBuilder) BuildSequenceValue(name string) (string, error) {
+	return b.db.QuoteTableName(name) + ".NEXTVAL", nil
+}
+
+// InsertReturningPK executes the INSERT query with a RETURNING INTO clause and populates dest with the value
+// of the named primary key column generated by the database through an output parameter.
+func (b *OciBuilder) InsertReturningPK(query *Query, pkName string, autoInc bool, dest interface{}) (bool, error) {
+	params := Params{}
+	for k, v := range query.Params() {
+		params[k] = v
+	}
+	name := fmt.Sprintf("p%v", len(params))
+	params[name] = sql.Out{Dest: dest}
+	returning := query.SQL() + " RETURNING " + b.db.QuoteColumnName(pkName) + " INTO {:" + name + "}"
+	_, err := b.NewQuery(returning).Bind(params).WithContext(query.Context()).Execute()
+	return true, err
+}
@@ -48,6 +48,33 @@ func TestOciQueryBuilder_BuildOrderByAndLimit(t *testing.T) {
 	assert.Equal(t, sql, expected, "t4")
 }
 
+func TestOciBuilder_BuildExists(t *testing.T) {
+	b := getOciBuilder().(*OciBuilder)
+	sql := b.BuildExists("SELECT * FROM users")
+	assert.Equal(t, "SELECT CASE WHEN EXISTS(SELECT * FROM users) THEN 1 ELSE 0 END FROM DUAL", sql)
+}
+
+func TestOciBuilder_BuildJoinType(t *testing.T) {
+	b := getOciBuilder().(*OciBuilder)
+
+	typ, err := b.BuildJoinType(JoinInfo{"CROSS JOIN LATERAL", "users", nil})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "CROSS APPLY", typ)
+	}
+	typ, err = b.BuildJoinType(JoinInfo{"NATURAL JOIN", "users", nil})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "NATURAL JOIN", typ)
+	}
+}
+
+func TestOciBuilder_BuildSequenceValue(t *testing.T) {
+	b := getOciBuilder().(*OciBuilder)
+	sql, err := b.BuildSequenceValue("users_seq")
+	if assert.Nil(t, err) {
+		assert.Equal(t, `"users_seq".NEXTVAL`, sql)
+	}
+}
+
 func getOciBuilder() Builder {
 	db := getDB()
 	b := NewOciBuilder(db, db.sqlDB)
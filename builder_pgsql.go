@@ -6,7 +6,6 @@ package dbx
 
 import (
 	"fmt"
-	"sort"
 	"strings"
 )
 
@@ -16,7 +15,13 @@ type PgsqlBuilder struct {
 	qb *BaseQueryBuilder
 }
 
-var _ Builder = &PgsqlBuilder{}
+var (
+	_ Builder           = &PgsqlBuilder{}
+	_ DistinctOnBuilder = &PgsqlBuilder{}
+	_ ReturningBuilder  = &PgsqlBuilder{}
+	_ SequenceBuilder   = &PgsqlBuilder{}
+	_ SyntaxBuilder     = &PgsqlBuilder{}
+)
 
 // NewPgsqlBuilder creates a new PgsqlBuilder instance.
 func NewPgsqlBuilder(db *DB, executor Executor) Builder {
@@ -44,6 +49,12 @@ func (b *PgsqlBuilder) GeneratePlaceholder(i int) string {
 	return fmt.Sprintf("$%v", i)
 }
 
+// SQLSyntax returns the lexical rules of the SQL dialect.
+// PostgreSQL supports backslash escapes in E'...' strings only.
+func (b *PgsqlBuilder) SQLSyntax() SQLSyntax {
+	return SQLSyntax{EscapeStrings: true}
+}
+
 // QueryBuilder returns the query builder supporting the current DB.
 func (b *PgsqlBuilder) QueryBuilder() QueryBuilder {
 	return b.qb
@@ -55,13 +66,9 @@ func (b *PgsqlBuilder) QueryBuilder() QueryBuilder {
 // The keys of cols are the column names, while the values of cols are the corresponding column
 // values to be inserted.
 func (b *PgsqlBuilder) Upsert(table string, cols Params, constraints ...string) *Query {
+	cols, names := upsertColumns(cols)
 	q := b.Insert(table, cols)
-
-	names := []string{}
-	for name := range cols {
-		names = append(names, name)
-	}
-	sort.Strings(names)
+	b.db.beginBuild(q.params)
 
 	lines := []string{}
 	for _, name := range names {
@@ -82,7 +89,11 @@ func (b *PgsqlBuilder) Upsert(table string, cols Params, constraints ...string)
 		q.sql += " ON CONFLICT DO UPDATE SET " + strings.Join(lines, ", ")
 	}
 
-	return b.NewQuery(q.sql).Bind(q.params)
+	uq := b.buildQuery(q.sql, q.params)
+	if q.LastError != nil {
+		uq.LastError = q.LastError
+	}
+	return uq
 }
 
 // DropIndex creates a Query that can be used to remove the named index from a table.
@@ -103,3 +114,20 @@ func (b *PgsqlBuilder) AlterColumn(table, col, typ string) *Query {
 	sql := fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v TYPE %v", b.db.QuoteTableName(table), col, typ)
 	return b.NewQuery(sql)
 }
+
+// BuildDistinctOn generates a DISTINCT ON option for the SELECT clause from the given columns.
+func (b *PgsqlBuilder) BuildDistinctOn(cols []string) (string, error) {
+	return "DISTINCT ON (" + b.quoteColumns(cols) + ")", nil
+}
+
+// BuildSequenceValue generates an expression that takes the next value from the named sequence.
+// The sequence name is quoted as a table name and passed to nextval as a string literal.
+func (b *PgsqlBuilder) BuildSequenceValue(name string) (string, error) {
+	return "nextval(" + b.Quote(b.db.QuoteTableName(name)) + ")", nil
+}
+
+// InsertReturningPK executes the INSERT query with a RETURNING clause and populates dest with the value
+// of the named primary key column generated by the database, as lib/pq does not support LastInsertId.
+func (b *PgsqlBuilder) InsertReturningPK(query *Query, pkName string, autoInc bool, dest interface{}) (bool, error) {
+	return true, b.queryReturning(query, query.SQL()+" RETURNING "+b.db.QuoteColumnName(pkName), dest)
+}
@@ -5,7 +5,10 @@
 package dbx
 
 import (
+	"context"
+	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -22,7 +25,16 @@ func TestPgsqlBuilder_Upsert(t *testing.T) {
 	assert.Equal(t, q.Params()["p1"], "James", "t4")
 	assert.Equal(t, q.Params()["p2"], 30, "t5")
 	assert.Equal(t, q.Params()["p3"], "James", "t6")
+
+	// insert-only columns are left out of the update
+	q = b.Upsert("users", Params{
+		"name":    "James",
+		"created": insertOnly{"2020-01-02"},
+	}, "id")
+	assert.Equal(t, q.rawSQL, `INSERT INTO "users" ("created", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name"=$3`, "t7")
+	assert.Equal(t, q.Params()["p0"], "2020-01-02", "t8")
 }
+
 func TestPgsqlBuilder_DropIndex(t *testing.T) {
 	b := getPgsqlBuilder()
 	q := b.DropIndex("users", "idx")
@@ -41,9 +53,43 @@ func TestPgsqlBuilder_AlterColumn(t *testing.T) {
 	assert.Equal(t, q.SQL(), `ALTER TABLE "users" ALTER COLUMN "name" TYPE int`, "t1")
 }
 
+func TestPgsqlBuilder_InsertReturningPK(t *testing.T) {
+	b := getPgsqlBuilder().(*PgsqlBuilder)
+	var queried string
+	b.db.QueryLogFunc = func(ctx context.Context, t time.Duration, s string, rows *sql.Rows, err error) {
+		queried = s
+	}
+
+	var id int
+	ok, _ := b.InsertReturningPK(b.Insert("users", Params{"name": "test"}), "id", true, &id)
+	assert.True(t, ok)
+	assert.Equal(t, `INSERT INTO "users" ("name") VALUES ('test') RETURNING "id"`, queried)
+}
+
 func getPgsqlBuilder() Builder {
 	db := getDB()
 	b := NewPgsqlBuilder(db, db.sqlDB)
 	db.Builder = b
 	return b
 }
+
+func TestPgsqlBuilder_BuildDistinctOn(t *testing.T) {
+	b := getPgsqlBuilder().(*PgsqlBuilder)
+
+	sql, err := b.BuildDistinctOn([]string{"name", "email"})
+	if assert.Nil(t, err) {
+		assert.Equal(t, `DISTINCT ON ("name", "email")`, sql)
+	}
+	_, err = buildIndexHint(b, IndexHint{"users", "USE", []string{"idx_name"}})
+	assert.NotNil(t, err)
+}
+
+func TestPgsqlBuilder_BuildSequenceValue(t *testing.T) {
+	b := getPgsqlBuilder().(*PgsqlBuilder)
+	sql, err := b.BuildSequenceValue("users_id_seq")
+	if assert.Nil(t, err) {
+		assert.Equal(t, `nextval('"users_id_seq"')`, sql)
+	}
+	sql, _ = b.BuildSequenceValue("public.it's_seq")
+	assert.Equal(t, `nextval('"public"."it''s_seq"')`, sql)
+}
@@ -16,7 +16,12 @@ type SqliteBuilder struct {
 	qb *BaseQueryBuilder
 }
 
-var _ Builder = &SqliteBuilder{}
+var (
+	_ Builder          = &SqliteBuilder{}
+	_ GroupingBuilder  = &SqliteBuilder{}
+	_ JoinTypeBuilder  = &SqliteBuilder{}
+	_ ReturningBuilder = &SqliteBuilder{}
+)
 
 // NewSqliteBuilder creates a new SqliteBuilder instance.
 func NewSqliteBuilder(db *DB, executor Executor) Builder {
@@ -126,3 +131,36 @@ func (b *SqliteBuilder) DropForeignKey(table, name string) *Query {
 	q.LastError = errors.New("SQLite does not support dropping foreign keys")
 	return q
 }
+
+// BuildGrouping generates a GROUP BY clause from the given group-by columns and grouping construct.
+func (b *SqliteBuilder) BuildGrouping(cols []string, grouping *GroupingInfo) (string, error) {
+	return "", errors.New("SQLite does not support " + grouping.Type)
+}
+
+// BuildGroupingFunc generates a GROUPING function call over the given columns.
+// SQLite does not support the GROUPING function, so the query using it fails when executed.
+func (b *SqliteBuilder) BuildGroupingFunc(cols []string) string {
+	return buildFuncCall(b.db, "GROUPING", cols)
+}
+
+// BuildJoinType generates the join operator used by the given JOIN clause.
+// SQLite does not support FULL JOIN and lateral joins.
+func (b *SqliteBuilder) BuildJoinType(join JoinInfo) (string, error) {
+	switch join.Join {
+	case "FULL JOIN":
+		return "", errors.New("SQLite does not support FULL JOIN")
+	case "CROSS JOIN LATERAL", "LEFT JOIN LATERAL":
+		return "", errors.New("SQLite does not support lateral joins")
+	}
+	return join.Join, nil
+}
+
+// InsertReturningPK executes the INSERT query with a RETURNING clause and populates dest with the value
+// of the named primary key column generated by the database. The auto-incremental primary keys are
+// obtained by LastInsertId instead.
+func (b *SqliteBuilder) InsertReturningPK(query *Query, pkName string, autoInc bool, dest interface{}) (bool, error) {
+	if autoInc {
+		return false, nil
+	}
+	return true, b.queryReturning(query, query.SQL()+" RETURNING "+b.db.QuoteColumnName(pkName), dest)
+}
@@ -5,7 +5,10 @@
 package dbx
 
 import (
+	"context"
+	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -81,6 +84,45 @@ func TestSqliteBuilder_DropForeignKey(t *testing.T) {
 	assert.NotEqual(t, q.LastError, nil, "t1")
 }
 
+func TestSqliteBuilder_BuildGrouping(t *testing.T) {
+	b := getSqliteBuilder().(*SqliteBuilder)
+	_, err := b.BuildGrouping(nil, &GroupingInfo{"ROLLUP", [][]string{{"year"}}})
+	assert.NotNil(t, err)
+}
+
+func TestSqliteBuilder_BuildJoinType(t *testing.T) {
+	b := getSqliteBuilder().(*SqliteBuilder)
+
+	typ, err := b.BuildJoinType(JoinInfo{"NATURAL JOIN", "users", nil})
+	if assert.Nil(t, err) {
+		assert.Equal(t, "NATURAL JOIN", typ)
+	}
+	_, err = b.BuildJoinType(JoinInfo{"FULL JOIN", "users", nil})
+	assert.NotNil(t, err)
+	_, err = b.BuildJoinType(JoinInfo{"LEFT JOIN LATERAL", "users", nil})
+	assert.NotNil(t, err)
+}
+
+func TestSqliteBuilder_InsertReturningPK(t *testing.T) {
+	b := getSqliteBuilder().(*SqliteBuilder)
+	var queried string
+	b.db.QueryLogFunc = func(ctx context.Context, t time.Duration, s string, rows *sql.Rows, err error) {
+		queried = s
+	}
+
+	// auto-incremental primary keys are obtained by LastInsertId
+	var id int
+	ok, err := b.InsertReturningPK(b.Insert("users", Params{"name": "test"}), "id", true, &id)
+	assert.False(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, "", queried)
+
+	var uuid string
+	ok, _ = b.InsertReturningPK(b.Insert("users", Params{"name": "test"}), "id", false, &uuid)
+	assert.True(t, ok)
+	assert.Equal(t, "INSERT INTO `users` (`name`) VALUES ('test') RETURNING `id`", queried)
+}
+
 func getSqliteBuilder() Builder {
 	db := getDB()
 	b := NewSqliteBuilder(db, db.sqlDB)
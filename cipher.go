@@ -0,0 +1,233 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dbx
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+type (
+	// Cipher encrypts and decrypts the values of the struct fields tagged with the "encrypted" option.
+	// A Cipher is registered as DB.Cipher.
+	Cipher interface {
+		// Encrypt encrypts a plaintext value to be stored in the database.
+		Encrypt(plaintext []byte) ([]byte, error)
+		// Decrypt decrypts a value read from the database.
+		Decrypt(ciphertext []byte) ([]byte, error)
+	}
+
+	// KeyProvider provides the keys used by AESCipher.
+	KeyProvider interface {
+		// CurrentKey returns the ID and the key used to encrypt new values.
+		CurrentKey() (string, []byte, error)
+		// Key returns the key with the given ID, which is used to decrypt the values encrypted with the key.
+		Key(id string) ([]byte, error)
+	}
+
+	// StaticKeyProvider is a KeyProvider holding the keys in memory, such as the keys loaded from local configuration.
+	StaticKeyProvider struct {
+		// CurrentID is the ID of the key used to encrypt new values.
+		CurrentID string
+		// Keys lists the 16, 24 or 32-byte AES keys indexed by their IDs. The IDs must not contain colons.
+		// Retired keys should be kept until all values encrypted with them are re-encrypted.
+		Keys map[string][]byte
+	}
+
+	// AESCipher is a Cipher using AES-GCM with the keys given by a KeyProvider.
+	//
+	// An encrypted value is stored as the ID of the key followed by a colon and the base64-encoded nonce and
+	// ciphertext, such as "v2:bm9uY2U...". Because the key ID is stored with each value, the current key can be
+	// rotated without re-encrypting the existing values at once: the values are decrypted using the keys they
+	// were encrypted with, and re-encrypted using the current key when they are saved again.
+	AESCipher struct {
+		keys KeyProvider
+	}
+)
+
+// CurrentKey returns the ID and the key used to encrypt new values.
+func (p *StaticKeyProvider) CurrentKey() (string, []byte, error) {
+	key, err := p.Key(p.CurrentID)
+	return p.CurrentID, key, err
+}
+
+// Key returns the key with the given ID.
+func (p *StaticKeyProvider) Key(id string) ([]byte, error) {
+	key, ok := p.Keys[id]
+	if !ok {
+		return nil, fmt.Errorf("encryption key %q is not found", id)
+	}
+	return key, nil
+}
+
+// NewAESCipher creates a new AESCipher using the keys given by the key provider.
+func NewAESCipher(keys KeyProvider) *AESCipher {
+	return &AESCipher{keys}
+}
+
+// Encrypt encrypts a plaintext value using the current key.
+func (c *AESCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	id, key, err := c.keys.CurrentKey()
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(id, ":") {
+		return nil, fmt.Errorf("encryption key ID %q must not contain colons", id)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	sealed := gcm.Seal(nonce, nonce, plaintext, []byte(id))
+	return []byte(id + ":" + base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+// Decrypt decrypts a value using the key whose ID prefixes the value.
+func (c *AESCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	parts := strings.SplitN(string(ciphertext), ":", 2)
+	if len(parts) != 2 {
+		return nil, errors.New("the encrypted value has no key ID")
+	}
+	key, err := c.keys.Key(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("the encrypted value is too short")
+	}
+	nonce, sealed := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, []byte(parts[0]))
+	if err == nil && plaintext == nil {
+		// an empty plaintext is not a NULL value
+		plaintext = []byte{}
+	}
+	return plaintext, err
+}
+
+// newGCM creates an AES-GCM cipher using the given key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptedValue encrypts a field value when it is saved in the database.
+// The value must be a string, a byte slice, or a driver.Valuer returning either of them.
+type encryptedValue struct {
+	value      interface{}
+	cipher     Cipher
+	converters map[reflect.Type]Converter // the converters applied to the value before encryption
+}
+
+// Value implements driver.Valuer.
+func (v encryptedValue) Value() (driver.Value, error) {
+	value, err := convertValue(v.value, v.converters)
+	if err != nil {
+		return nil, err
+	}
+	if valuer, ok := value.(driver.Valuer); ok {
+		if value, err = valuer.Value(); err != nil {
+			return nil, err
+		}
+	}
+	var plaintext []byte
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		plaintext = []byte(value)
+	case []byte:
+		plaintext = value
+	default:
+		return nil, VarTypeError("encrypted fields must be strings or byte slices")
+	}
+	if v.cipher == nil {
+		return nil, errors.New("DB.Cipher must be set to encrypt fields")
+	}
+	ciphertext, err := v.cipher.Encrypt(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return string(ciphertext), nil
+}
+
+// encryptedScanner decrypts a column value and populates it into the target,
+// which is a sql.Scanner, or a pointer to a string, a byte slice or a string pointer.
+type encryptedScanner struct {
+	target interface{}
+	cipher Cipher
+}
+
+// Scan implements sql.Scanner.
+func (s encryptedScanner) Scan(src interface{}) error {
+	var plaintext []byte
+	switch src := src.(type) {
+	case nil:
+	case []byte, string:
+		if s.cipher == nil {
+			return errors.New("DB.Cipher must be set to decrypt fields")
+		}
+		var ciphertext []byte
+		if b, ok := src.([]byte); ok {
+			ciphertext = b
+		} else {
+			ciphertext = []byte(src.(string))
+		}
+		var err error
+		if plaintext, err = s.cipher.Decrypt(ciphertext); err != nil {
+			return err
+		}
+		if plaintext == nil {
+			plaintext = []byte{}
+		}
+	default:
+		return fmt.Errorf("cannot decrypt %T", src)
+	}
+
+	switch target := s.target.(type) {
+	case sql.Scanner:
+		if plaintext == nil {
+			return target.Scan(nil)
+		}
+		return target.Scan(plaintext)
+	case *string:
+		*target = string(plaintext)
+	case *[]byte:
+		*target = plaintext
+	case **string:
+		if plaintext == nil {
+			*target = nil
+		} else {
+			str := string(plaintext)
+			*target = &str
+		}
+	default:
+		return VarTypeError("encrypted fields must be strings or byte slices")
+	}
+	return nil
+}
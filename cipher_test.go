@@ -0,0 +1,208 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dbx
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getTestCipher() (*AESCipher, *StaticKeyProvider) {
+	keys := &StaticKeyProvider{
+		CurrentID: "v1",
+		Keys: map[string][]byte{
+			"v1": []byte("0123456789abcdef0123456789abcdef"),
+			"v2": []byte("fedcba9876543210"),
+		},
+	}
+	return NewAESCipher(keys), keys
+}
+
+func TestAESCipher(t *testing.T) {
+	c, keys := getTestCipher()
+
+	ciphertext, err := c.Encrypt([]byte("secret"))
+	if assert.Nil(t, err) {
+		assert.True(t, strings.HasPrefix(string(ciphertext), "v1:"))
+		assert.NotContains(t, string(ciphertext), "secret")
+		plaintext, err := c.Decrypt(ciphertext)
+		assert.Nil(t, err)
+		assert.Equal(t, "secret", string(plaintext))
+	}
+	empty, _ := c.Encrypt([]byte(""))
+	plaintext, err := c.Decrypt(empty)
+	if assert.Nil(t, err) {
+		assert.NotNil(t, plaintext)
+		assert.Empty(t, plaintext)
+	}
+
+	ciphertext2, _ := c.Encrypt([]byte("secret"))
+	assert.NotEqual(t, ciphertext, ciphertext2)
+
+	// rotate the key
+	keys.CurrentID = "v2"
+	ciphertext3, err := c.Encrypt([]byte("secret"))
+	if assert.Nil(t, err) {
+		assert.True(t, strings.HasPrefix(string(ciphertext3), "v2:"))
+		plaintext, _ := c.Decrypt(ciphertext3)
+		assert.Equal(t, "secret", string(plaintext))
+	}
+	plaintext, err = c.Decrypt(ciphertext)
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+
+	// the key ID prefix is authenticated
+	_, err = c.Decrypt([]byte("v2" + string(ciphertext[2:])))
+	assert.NotNil(t, err)
+	_, err = c.Decrypt([]byte("v3:" + string(ciphertext[3:])))
+	assert.NotNil(t, err)
+	_, err = c.Decrypt([]byte("secret"))
+	assert.NotNil(t, err)
+	_, err = c.Decrypt([]byte("v1:!"))
+	assert.NotNil(t, err)
+	_, err = c.Decrypt([]byte("v1:YWJj"))
+	assert.NotNil(t, err)
+
+	keys.CurrentID = "v3"
+	_, err = c.Encrypt([]byte("secret"))
+	assert.NotNil(t, err)
+	keys.CurrentID = "v:1"
+	keys.Keys["v:1"] = keys.Keys["v1"]
+	_, err = c.Encrypt([]byte("secret"))
+	assert.NotNil(t, err)
+}
+
+func Test_encryptedValue(t *testing.T) {
+	c, _ := getTestCipher()
+
+	value, err := encryptedValue{"secret", c, nil}.Value()
+	if assert.Nil(t, err) {
+		plaintext, _ := c.Decrypt([]byte(value.(string)))
+		assert.Equal(t, "secret", string(plaintext))
+	}
+	value, err = encryptedValue{jsonValue{[]int{1}}, c, nil}.Value()
+	if assert.Nil(t, err) {
+		plaintext, _ := c.Decrypt([]byte(value.(string)))
+		assert.Equal(t, "[1]", string(plaintext))
+	}
+	value, err = encryptedValue{nil, c, nil}.Value()
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+
+	_, err = encryptedValue{1, c, nil}.Value()
+	assert.NotNil(t, err)
+	_, err = encryptedValue{"secret", nil, nil}.Value()
+	assert.NotNil(t, err)
+}
+
+func Test_encryptedScanner(t *testing.T) {
+	c, _ := getTestCipher()
+	ciphertext, _ := c.Encrypt([]byte("secret"))
+
+	var s string
+	assert.Nil(t, encryptedScanner{&s, c}.Scan(ciphertext))
+	assert.Equal(t, "secret", s)
+	assert.Nil(t, encryptedScanner{&s, c}.Scan(nil))
+	assert.Equal(t, "", s)
+
+	var b []byte
+	assert.Nil(t, encryptedScanner{&b, c}.Scan(string(ciphertext)))
+	assert.Equal(t, []byte("secret"), b)
+
+	var ps *string
+	assert.Nil(t, encryptedScanner{&ps, c}.Scan(ciphertext))
+	if assert.NotNil(t, ps) {
+		assert.Equal(t, "secret", *ps)
+	}
+	assert.Nil(t, encryptedScanner{&ps, c}.Scan(nil))
+	assert.Nil(t, ps)
+
+	var ns sql.NullString
+	assert.Nil(t, encryptedScanner{&ns, c}.Scan(ciphertext))
+	assert.Equal(t, sql.NullString{String: "secret", Valid: true}, ns)
+
+	// an encrypted empty string is not read back as NULL
+	value, _ := encryptedValue{"", c, nil}.Value()
+	assert.Nil(t, encryptedScanner{&ps, c}.Scan(value))
+	if assert.NotNil(t, ps) {
+		assert.Equal(t, "", *ps)
+	}
+	assert.Nil(t, encryptedScanner{&b, c}.Scan(value))
+	assert.Equal(t, []byte{}, b)
+	assert.Nil(t, encryptedScanner{&ns, c}.Scan(value))
+	assert.Equal(t, sql.NullString{String: "", Valid: true}, ns)
+
+	var i int
+	assert.NotNil(t, encryptedScanner{&i, c}.Scan(ciphertext))
+	assert.NotNil(t, encryptedScanner{&s, c}.Scan(1))
+	assert.NotNil(t, encryptedScanner{&s, nil}.Scan(ciphertext))
+	assert.NotNil(t, encryptedScanner{&s, c}.Scan([]byte("v1:abc")))
+}
+
+func Test_structValue_encrypted(t *testing.T) {
+	c, _ := getTestCipher()
+	db := getDB()
+	db.Cipher = c
+
+	type Account struct {
+		ID    int
+		SSN   string            `db:"ssn,encrypted"`
+		Notes map[string]string `db:"notes,json,encrypted"`
+	}
+	account := Account{ID: 1, SSN: "123-45-6789", Notes: map[string]string{"a": "b"}}
+	sv := newStructValue(&account, DefaultFieldMapFunc, nil, GetTableName)
+
+	params := sv.params(sv.columns(nil, nil), db)
+	assert.Equal(t, 1, params["id"])
+	value, err := params["ssn"].(driver.Valuer).Value()
+	if assert.Nil(t, err) {
+		assert.NotEqual(t, "123-45-6789", value)
+		plaintext, _ := c.Decrypt([]byte(value.(string)))
+		assert.Equal(t, "123-45-6789", string(plaintext))
+
+		var account2 Account
+		v := reflect.ValueOf(&account2).Elem()
+		assert.Nil(t, sv.nameMap["SSN"].scanTarget(v, db).(sql.Scanner).Scan(value))
+		assert.Equal(t, "123-45-6789", account2.SSN)
+	}
+	value, err = params["notes"].(driver.Valuer).Value()
+	if assert.Nil(t, err) {
+		var account2 Account
+		v := reflect.ValueOf(&account2).Elem()
+		assert.Nil(t, sv.nameMap["Notes"].scanTarget(v, db).(sql.Scanner).Scan([]byte(value.(string))))
+		assert.Equal(t, map[string]string{"a": "b"}, account2.Notes)
+	}
+}
+
+func Test_structValue_encryptedConverter(t *testing.T) {
+	c, _ := getTestCipher()
+	db := getDB()
+	db.Cipher = c
+	db.Converters = testConverters
+
+	type Account struct {
+		ID      int
+		Balance money `db:"balance,encrypted"`
+	}
+	account := Account{ID: 1, Balance: money{1234}}
+	sv := newStructValue(&account, DefaultFieldMapFunc, db.Converters, GetTableName)
+
+	// the value is converted before being encrypted
+	value, err := sv.params(sv.columns(nil, nil), db)["balance"].(driver.Valuer).Value()
+	if assert.Nil(t, err) {
+		plaintext, _ := c.Decrypt([]byte(value.(string)))
+		assert.Equal(t, "12.34", string(plaintext))
+
+		var account2 Account
+		v := reflect.ValueOf(&account2).Elem()
+		assert.Nil(t, sv.nameMap["Balance"].scanTarget(v, db).(sql.Scanner).Scan(value))
+		assert.Equal(t, money{1234}, account2.Balance)
+	}
+}
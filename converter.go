@@ -0,0 +1,81 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dbx
+
+import (
+	"reflect"
+)
+
+// Converter converts between the values of a Go type and the values stored in the database.
+//
+// Converters are registered in DB.Converters, indexed by the Go types they support. They allow storing and loading
+// the types which do not implement sql.Scanner and driver.Valuer, such as the types from third-party packages.
+type Converter interface {
+	// ToDB converts a value of the Go type into a value which can be passed to the database driver,
+	// such as int64, float64, bool, []byte, string, time.Time or nil.
+	ToDB(value interface{}) (interface{}, error)
+	// FromDB populates the Go value pointed to by dest with the value read from the database.
+	// The src value is nil or one of the types returned by the database driver, such as int64, float64,
+	// bool, []byte, string and time.Time.
+	FromDB(src interface{}, dest interface{}) error
+}
+
+// converterScanner populates a variable using a Converter.
+type converterScanner struct {
+	converter Converter
+	value     reflect.Value // the variable to be populated
+	ptr       bool          // whether the variable is a pointer to the type supported by the converter
+}
+
+// Scan implements sql.Scanner.
+func (s converterScanner) Scan(src interface{}) error {
+	value := s.value
+	if s.ptr {
+		if src == nil {
+			value.Set(reflect.Zero(value.Type()))
+			return nil
+		}
+		value.Set(reflect.New(value.Type().Elem()))
+		value = value.Elem()
+	}
+	return s.converter.FromDB(src, value.Addr().Interface())
+}
+
+// scanTarget returns the pointer to be passed to Rows.Scan for populating the given addressable value.
+// If a converter is registered for the type of the value or the type pointed to by the value,
+// the value is populated using the converter.
+func scanTarget(v reflect.Value, converters map[reflect.Type]Converter) interface{} {
+	if c, ok := converters[v.Type()]; ok {
+		return converterScanner{c, v, false}
+	}
+	if v.Kind() == reflect.Ptr {
+		if c, ok := converters[v.Type().Elem()]; ok {
+			return converterScanner{c, v, true}
+		}
+	}
+	return v.Addr().Interface()
+}
+
+// convertValue converts a query parameter value using the converter registered for its type or the type
+// it points to. The value is returned as is if there is no such converter.
+func convertValue(value interface{}, converters map[reflect.Type]Converter) (interface{}, error) {
+	if len(converters) == 0 || value == nil {
+		return value, nil
+	}
+	t := reflect.TypeOf(value)
+	if c, ok := converters[t]; ok {
+		return c.ToDB(value)
+	}
+	if t.Kind() == reflect.Ptr {
+		if c, ok := converters[t.Elem()]; ok {
+			v := reflect.ValueOf(value)
+			if v.IsNil() {
+				return nil, nil
+			}
+			return c.ToDB(v.Elem().Interface())
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dbx
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// money is a foreign-like type which neither implements sql.Scanner nor driver.Valuer.
+type money struct {
+	cents int64
+}
+
+type moneyConverter struct{}
+
+func (moneyConverter) ToDB(value interface{}) (interface{}, error) {
+	return fmt.Sprintf("%.2f", float64(value.(money).cents)/100), nil
+}
+
+func (moneyConverter) FromDB(src interface{}, dest interface{}) error {
+	var s string
+	switch src := src.(type) {
+	case []byte:
+		s = string(src)
+	case string:
+		s = src
+	case nil:
+		*dest.(*money) = money{}
+		return nil
+	default:
+		return errors.New("unsupported money value")
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*dest.(*money) = money{int64(f*100 + 0.5)}
+	return nil
+}
+
+var testConverters = map[reflect.Type]Converter{
+	reflect.TypeOf(money{}): moneyConverter{},
+}
+
+func Test_convertValue(t *testing.T) {
+	value, err := convertValue(money{1234}, testConverters)
+	assert.Nil(t, err)
+	assert.Equal(t, "12.34", value)
+
+	value, err = convertValue(&money{5}, testConverters)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.05", value)
+
+	value, err = convertValue((*money)(nil), testConverters)
+	assert.Nil(t, err)
+	assert.Nil(t, value)
+
+	value, _ = convertValue(1, testConverters)
+	assert.Equal(t, 1, value)
+	value, _ = convertValue(nil, testConverters)
+	assert.Nil(t, value)
+	value, _ = convertValue(money{1}, nil)
+	assert.Equal(t, money{1}, value)
+}
+
+func Test_scanTarget(t *testing.T) {
+	var model struct {
+		Price    money
+		Discount *money
+		Count    int
+	}
+	v := reflect.ValueOf(&model).Elem()
+
+	s, ok := scanTarget(v.Field(0), testConverters).(converterScanner)
+	if assert.True(t, ok) {
+		assert.Nil(t, s.Scan([]byte("10.50")))
+		assert.Equal(t, money{1050}, model.Price)
+		assert.NotNil(t, s.Scan(1))
+	}
+
+	s, ok = scanTarget(v.Field(1), testConverters).(converterScanner)
+	if assert.True(t, ok) {
+		assert.Nil(t, s.Scan("0.25"))
+		assert.Equal(t, &money{25}, model.Discount)
+		assert.Nil(t, s.Scan(nil))
+		assert.Nil(t, model.Discount)
+	}
+
+	_, ok = scanTarget(v.Field(2), testConverters).(*int)
+	assert.True(t, ok)
+	_, ok = scanTarget(v.Field(0), nil).(*money)
+	assert.True(t, ok)
+}
+
+func Test_structInfo_opaqueStruct(t *testing.T) {
+	type Product struct {
+		ID    int
+		Price money
+	}
+	si := getStructInfo(reflect.TypeOf(Product{}), DefaultFieldMapFunc, testConverters)
+	if assert.Contains(t, si.dbNameMap, "price") {
+		assert.Equal(t, "Price", si.dbNameMap["price"].name)
+	}
+
+	// structs without exported fields are ignored unless they have converters or are both valuers and scanners
+	si = getStructInfo(reflect.TypeOf(Product{}), DefaultFieldMapFunc, nil)
+	assert.NotContains(t, si.dbNameMap, "price")
+
+	type Order struct {
+		sync.Mutex
+		ID    int
+		Lock  sync.Mutex
+		Code  opaqueCode
+		Label opaqueLabel
+		Price *money
+	}
+	si = getStructInfo(reflect.TypeOf(Order{}), DefaultFieldMapFunc, testConverters)
+	assert.Equal(t, []string{"Code", "ID", "Price"}, sortedKeys(si.nameMap))
+	si = getStructInfo(reflect.TypeOf(Order{}), DefaultFieldMapFunc, nil)
+	assert.Equal(t, []string{"Code", "ID"}, sortedKeys(si.nameMap))
+}
+
+// opaqueCode is a valuer and scanner without exported fields.
+type opaqueCode struct {
+	code string
+}
+
+func (c opaqueCode) Value() (driver.Value, error) {
+	return c.code, nil
+}
+
+func (c *opaqueCode) Scan(value interface{}) error {
+	c.code = fmt.Sprint(value)
+	return nil
+}
+
+// opaqueLabel is a valuer without exported fields, which cannot be populated.
+type opaqueLabel struct {
+	label string
+}
+
+func (l opaqueLabel) Value() (driver.Value, error) {
+	return l.label, nil
+}
+
+func sortedKeys(m map[string]*fieldInfo) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,224 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dbx
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// InvalidCursorError is returned by SelectQuery.AllByCursor when the given cursor cannot be decoded
+// or does not match the key columns.
+var InvalidCursorError = errors.New("invalid cursor")
+
+// CursorPage contains the cursors for navigating from a page fetched by SelectQuery.AllByCursor.
+type CursorPage struct {
+	// Next is the cursor of the page following the current one. It is empty if there is no next page.
+	Next string
+	// Prev is the cursor of the page preceding the current one. It is empty if there is no previous page.
+	Prev string
+}
+
+// cursorToken is the decoded form of a cursor.
+type cursorToken struct {
+	Prev   bool          `json:"p,omitempty"`
+	Values []interface{} `json:"v"`
+}
+
+// keysetExp represents the condition selecting the rows that come after a set of key values
+// according to the ordering directions of the key columns.
+type keysetExp struct {
+	cols   []string
+	desc   []bool
+	values []interface{}
+}
+
+// AllByCursor executes the SELECT query using keyset pagination and populates the rows of
+// the requested page into a slice of struct or NullStringMap.
+//
+// The keys parameter lists the columns that determine the order of the rows. Each key column may
+// contain "ASC" or "DESC" to indicate its ordering direction, and the combination of the key columns
+// must uniquely identify a row. The ORDER BY clause of the query is replaced with the key columns.
+//
+// An empty cursor fetches the first page. The cursors returned in CursorPage can be passed back to
+// fetch the next or the previous page. At most limit rows are populated into the slice.
+//
+// The query itself is not modified by this method.
+func (s *SelectQuery) AllByCursor(slice interface{}, cursor string, limit int64, keys ...string) (*CursorPage, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one key column is required for cursor pagination")
+	}
+	token, err := decodeCursor(cursor, len(keys))
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make([]string, len(keys))
+	desc := make([]bool, len(keys))
+	orderBy := make([]string, len(keys))
+	for i, key := range keys {
+		cols[i] = key
+		if matches := orderRegex.FindStringSubmatch(key); len(matches) > 0 {
+			cols[i] = key[:len(key)-len(matches[0])]
+			desc[i] = strings.EqualFold(matches[1], "DESC")
+		}
+		// the previous page is fetched in reverse order and reversed back afterwards
+		desc[i] = desc[i] != token.Prev
+		if desc[i] {
+			orderBy[i] = cols[i] + " DESC"
+		} else {
+			orderBy[i] = cols[i] + " ASC"
+		}
+	}
+
+	q := s.Clone()
+	q.orderBy = orderBy
+	q.offset = -1
+	if limit >= 0 {
+		q.limit = limit + 1
+	}
+	if token.Values != nil {
+		q.AndWhere(&keysetExp{cols, desc, token.Values})
+	}
+	// All appends to the slice, so only the rows after the existing elements belong to the page
+	n := 0
+	if v := reflect.ValueOf(slice); v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Slice {
+		n = v.Elem().Len()
+	}
+	if err := q.All(slice); err != nil {
+		return nil, err
+	}
+
+	v := indirect(reflect.ValueOf(slice))
+	hasMore := limit >= 0 && int64(v.Len()-n) > limit
+	if hasMore {
+		v.SetLen(n + int(limit))
+	}
+	if token.Prev {
+		swap := reflect.Swapper(v.Slice(n, v.Len()).Interface())
+		for i, j := 0, v.Len()-n-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+
+	page := &CursorPage{}
+	if v.Len() == n {
+		return page, nil
+	}
+	if hasMore || token.Prev {
+		if page.Next, err = encodeCursor(false, v.Index(v.Len()-1), cols, q.FieldMapper, q.converters()); err != nil {
+			return nil, err
+		}
+	}
+	if token.Values != nil && (hasMore || !token.Prev) {
+		if page.Prev, err = encodeCursor(true, v.Index(n), cols, q.FieldMapper, q.converters()); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// Build converts an expression into a SQL fragment.
+func (e *keysetExp) Build(db *DB, params Params) string {
+	parts := make([]string, len(e.cols))
+	for i := range e.cols {
+		var conds []string
+		for j := 0; j <= i; j++ {
+			op := "="
+			if j == i {
+				op = ">"
+				if e.desc[i] {
+					op = "<"
+				}
+			}
+			name := fmt.Sprintf("p%v", len(params))
+			params[name] = e.values[j]
+			conds = append(conds, fmt.Sprintf("%v%v{:%v}", db.QuoteColumnName(e.cols[j]), op, name))
+		}
+		parts[i] = strings.Join(conds, " AND ")
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return "(" + strings.Join(parts, ") OR (") + ")"
+}
+
+// encodeCursor creates a cursor from the key column values of the given row.
+func encodeCursor(prev bool, row reflect.Value, cols []string, mapper FieldMapFunc, converters map[reflect.Type]Converter) (string, error) {
+	token := cursorToken{Prev: prev, Values: make([]interface{}, len(cols))}
+	for i, col := range cols {
+		value, ok := keyValue(row, col, mapper, converters)
+		if !ok {
+			return "", fmt.Errorf("key column %v is not found in the query result", col)
+		}
+		if valuer, ok := value.(driver.Valuer); ok {
+			var err error
+			if value, err = valuer.Value(); err != nil {
+				return "", err
+			}
+		}
+		token.Values[i] = value
+	}
+	s, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(s), nil
+}
+
+// decodeCursor decodes the given cursor which should contain n key column values.
+func decodeCursor(cursor string, n int) (cursorToken, error) {
+	var token cursorToken
+	if cursor == "" {
+		return token, nil
+	}
+	s, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return token, InvalidCursorError
+	}
+	d := json.NewDecoder(bytes.NewReader(s))
+	d.UseNumber()
+	if err := d.Decode(&token); err != nil || len(token.Values) != n {
+		return token, InvalidCursorError
+	}
+	for i, value := range token.Values {
+		if number, ok := value.(json.Number); ok {
+			if v, err := number.Int64(); err == nil {
+				token.Values[i] = v
+			} else if v, err := number.Float64(); err == nil {
+				token.Values[i] = v
+			}
+		}
+	}
+	return token, nil
+}
+
+// keyValue returns the value of the named column in the given struct or NullStringMap.
+func keyValue(row reflect.Value, col string, mapper FieldMapFunc, converters map[reflect.Type]Converter) (interface{}, bool) {
+	names := []string{col}
+	if pos := strings.LastIndex(col, "."); pos != -1 {
+		names = append(names, col[pos+1:])
+	}
+	row = reflect.Indirect(row)
+	for _, name := range names {
+		switch row.Kind() {
+		case reflect.Map:
+			if v := row.MapIndex(reflect.ValueOf(name)); v.IsValid() {
+				return v.Interface(), true
+			}
+		case reflect.Struct:
+			if fi, ok := getStructInfo(row.Type(), mapper, converters).dbNameMap[name]; ok {
+				return fi.getValue(row), true
+			}
+		}
+	}
+	return nil, false
+}
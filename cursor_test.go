@@ -0,0 +1,110 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dbx
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeysetExp(t *testing.T) {
+	db := getDB()
+
+	params := Params{}
+	e := &keysetExp{[]string{"id"}, []bool{false}, []interface{}{10}}
+	assert.Equal(t, "`id`>{:p0}", e.Build(db, params))
+	assert.Equal(t, Params{"p0": 10}, params)
+
+	params = Params{}
+	e = &keysetExp{[]string{"status", "id"}, []bool{true, false}, []interface{}{2, 10}}
+	assert.Equal(t, "(`status`<{:p0}) OR (`status`={:p1} AND `id`>{:p2})", e.Build(db, params))
+	assert.Equal(t, Params{"p0": 2, "p1": 2, "p2": 10}, params)
+}
+
+func Test_decodeCursor(t *testing.T) {
+	token, err := decodeCursor("", 2)
+	if assert.Nil(t, err) {
+		assert.Nil(t, token.Values)
+	}
+
+	_, err = decodeCursor("!!", 1)
+	assert.Equal(t, InvalidCursorError, err)
+
+	customer := Customer{ID: 3, Email: "a@example.com"}
+	cursor, err := encodeCursor(true, reflect.ValueOf(customer), []string{"id", "customer.email"}, DefaultFieldMapFunc, nil)
+	if assert.Nil(t, err) {
+		token, err = decodeCursor(cursor, 2)
+		if assert.Nil(t, err) {
+			assert.True(t, token.Prev)
+			assert.Equal(t, []interface{}{int64(3), "a@example.com"}, token.Values)
+		}
+		_, err = decodeCursor(cursor, 1)
+		assert.Equal(t, InvalidCursorError, err)
+	}
+
+	_, err = encodeCursor(false, reflect.ValueOf(customer), []string{"unknown"}, DefaultFieldMapFunc, nil)
+	assert.NotNil(t, err)
+}
+
+func TestSelectQuery_AllByCursor(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	q := db.Select().From("customer")
+
+	var customers []Customer
+	page, err := q.AllByCursor(&customers, "", 2, "status DESC", "id")
+	if assert.Nil(t, err) && assert.Equal(t, 2, len(customers)) {
+		assert.Equal(t, 3, customers[0].ID)
+		assert.Equal(t, 1, customers[1].ID)
+		assert.Equal(t, "", page.Prev)
+		assert.NotEqual(t, "", page.Next)
+	}
+	assert.Equal(t, 0, len(q.orderBy))
+	assert.Equal(t, int64(-1), q.limit)
+
+	customers = nil
+	page, err = q.AllByCursor(&customers, page.Next, 2, "status DESC", "id")
+	if assert.Nil(t, err) && assert.Equal(t, 1, len(customers)) {
+		assert.Equal(t, 2, customers[0].ID)
+		assert.NotEqual(t, "", page.Prev)
+		assert.Equal(t, "", page.Next)
+	}
+
+	customers = nil
+	page, err = q.AllByCursor(&customers, page.Prev, 2, "status DESC", "id")
+	if assert.Nil(t, err) && assert.Equal(t, 2, len(customers)) {
+		assert.Equal(t, 3, customers[0].ID)
+		assert.Equal(t, 1, customers[1].ID)
+		assert.Equal(t, "", page.Prev)
+		assert.NotEqual(t, "", page.Next)
+	}
+
+	// rows already in the slice are kept and are not counted in the page
+	customers = []Customer{{ID: 100}}
+	page, err = q.AllByCursor(&customers, page.Next, 2, "status DESC", "id")
+	if assert.Nil(t, err) && assert.Equal(t, 2, len(customers)) {
+		assert.Equal(t, 100, customers[0].ID)
+		assert.Equal(t, 2, customers[1].ID)
+		assert.NotEqual(t, "", page.Prev)
+		assert.Equal(t, "", page.Next)
+	}
+	customers = []Customer{{ID: 100}}
+	page, err = q.AllByCursor(&customers, page.Prev, 2, "status DESC", "id")
+	if assert.Nil(t, err) && assert.Equal(t, 3, len(customers)) {
+		assert.Equal(t, 100, customers[0].ID)
+		assert.Equal(t, 3, customers[1].ID)
+		assert.Equal(t, 1, customers[2].ID)
+		assert.Equal(t, "", page.Prev)
+		assert.NotEqual(t, "", page.Next)
+	}
+
+	_, err = q.AllByCursor(&customers, "", 2)
+	assert.NotNil(t, err)
+	_, err = q.AllByCursor(&customers, "xyz", 2, "id")
+	assert.Equal(t, InvalidCursorError, err)
+}
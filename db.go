@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"reflect"
 	"regexp"
 	"strings"
 	"time"
@@ -41,6 +42,13 @@ type (
 	// BuilderFunc creates a Builder instance using the given DB instance and Executor.
 	BuilderFunc func(*DB, Executor) Builder
 
+	// KeyGenerator generates a primary key value on the client side before a model is inserted.
+	KeyGenerator func() (interface{}, error)
+
+	// StrictMode specifies the checks performed when populating query results into structs.
+	// The checks can be combined, such as StrictColumns|StrictFields.
+	StrictMode int
+
 	// DB enhances sql.DB by providing a set of DB-agnostic query building methods.
 	// DB allows easier query building and population of data into Go variables.
 	DB struct {
@@ -59,14 +67,52 @@ type (
 		QueryLogFunc QueryLogFunc
 		// ExecLogFunc is called each time when a SQL statement is executed.
 		ExecLogFunc ExecLogFunc
+		// KeyGenerators lists the primary key generators which can be referenced by the "generator"
+		// option of the db tag, indexed by their names.
+		KeyGenerators map[string]KeyGenerator
+		// NowFunc returns the current time used to fill the fields tagged with the "autoCreateTime" or
+		// "autoUpdateTime" option. Defaults to time.Now.
+		NowFunc func() time.Time
+		// Converters lists the converters used to bind query parameters and to populate query results
+		// of the Go types that do not implement driver.Valuer and sql.Scanner, indexed by the Go types.
+		Converters map[reflect.Type]Converter
+		// Cipher encrypts and decrypts the values of the fields tagged with the "encrypted" option.
+		// Defaults to nil, meaning such fields cannot be saved or populated.
+		Cipher Cipher
+		// StrictMode specifies the checks performed when populating query results into structs.
+		// Defaults to 0, meaning the result columns not mapped to any field and the fields not populated
+		// by any column are ignored.
+		StrictMode StrictMode
 
 		sqlDB      *sql.DB
 		driverName string
 		ctx        context.Context
+		builds     *buildErrors
 	}
 
 	// Errors represents a list of errors.
 	Errors []error
+
+	// SQLSyntax describes the lexical rules of a SQL dialect which affect how the SQL statements are scanned for
+	// parameter placeholders and quoted table/column names.
+	SQLSyntax struct {
+		// BackslashEscapes indicates that a backslash escapes the next character in quoted strings, as in MySQL.
+		BackslashEscapes bool
+		// EscapeStrings indicates that a backslash escapes the next character in E'...' strings, as in PostgreSQL.
+		EscapeStrings bool
+		// HashComments indicates that "#" starts a comment ending at the end of the line, as in MySQL.
+		HashComments bool
+		// SpacedDashComments indicates that "--" starts a comment only if it is followed by a whitespace or
+		// control character or by the end of the statement, as in MySQL. Otherwise, "5--3" is an expression.
+		SpacedDashComments bool
+	}
+
+	// SyntaxBuilder is implemented by the builders whose SQL dialect has lexical rules other than
+	// those of the standard SQL. The builders not implementing it use the standard rules.
+	SyntaxBuilder interface {
+		// SQLSyntax returns the lexical rules of the SQL dialect.
+		SQLSyntax() SQLSyntax
+	}
 )
 
 // BuilderFuncMap lists supported BuilderFunc according to DB driver names.
@@ -88,6 +134,8 @@ func NewFromDB(sqlDB *sql.DB, driverName string) *DB {
 		sqlDB:       sqlDB,
 		FieldMapper: DefaultFieldMapFunc,
 		TableMapper: GetTableName,
+		NowFunc:     time.Now,
+		builds:      &buildErrors{},
 	}
 	db.Builder = db.newBuilder(db.sqlDB)
 	return db
@@ -121,14 +169,20 @@ func MustOpen(driverName, dsn string) (*DB, error) {
 // Clone makes a shallow copy of DB.
 func (db *DB) Clone() *DB {
 	db2 := &DB{
-		driverName:   db.driverName,
-		sqlDB:        db.sqlDB,
-		FieldMapper:  db.FieldMapper,
-		TableMapper:  db.TableMapper,
-		PerfFunc:     db.PerfFunc,
-		LogFunc:      db.LogFunc,
-		QueryLogFunc: db.QueryLogFunc,
-		ExecLogFunc:  db.ExecLogFunc,
+		driverName:    db.driverName,
+		sqlDB:         db.sqlDB,
+		FieldMapper:   db.FieldMapper,
+		TableMapper:   db.TableMapper,
+		PerfFunc:      db.PerfFunc,
+		LogFunc:       db.LogFunc,
+		QueryLogFunc:  db.QueryLogFunc,
+		ExecLogFunc:   db.ExecLogFunc,
+		KeyGenerators: db.KeyGenerators,
+		NowFunc:       db.NowFunc,
+		Converters:    db.Converters,
+		Cipher:        db.Cipher,
+		StrictMode:    db.StrictMode,
+		builds:        db.builds,
 	}
 	db2.Builder = db2.newBuilder(db.sqlDB)
 	return db2
@@ -290,30 +344,158 @@ func (db *DB) QuoteColumnName(s string) string {
 }
 
 var (
-	plRegex    = regexp.MustCompile(`\{:\w+\}`)
-	quoteRegex = regexp.MustCompile(`(\{\{[\w\-\. ]+\}\}|\[\[[\w\-\. ]+\]\])`)
+	plRegex     = regexp.MustCompile(`^\{:(\w+)\}`)
+	tableRegex  = regexp.MustCompile(`^\{\{([\w\-\. ]+)\}\}`)
+	columnRegex = regexp.MustCompile(`^\[\[([\w\-\. ]+)\]\]`)
+	dollarRegex = regexp.MustCompile(`^\$([A-Za-z_]\w*)?\$`)
 )
 
 // processSQL replaces the named param placeholders in the given SQL with anonymous ones.
 // It also quotes table names and column names found in the SQL if these names are enclosed
 // within double square/curly brackets. The method will return the updated SQL and the list of parameter names.
+//
+// String literals, quoted identifiers, comments and PostgreSQL dollar-quoted strings are left untouched.
+// The lexical rules specific to the SQL dialect, such as backslash escapes in string literals, are given by
+// the builder if it implements SyntaxBuilder.
+// A placeholder or a table/column name token may be prefixed with a backslash (e.g. `\{:name}`)
+// so that it is kept literally without the backslash.
 func (db *DB) processSQL(s string) (string, []string) {
 	var placeholders []string
-	count := 0
-	s = plRegex.ReplaceAllStringFunc(s, func(m string) string {
-		count++
-		placeholders = append(placeholders, m[2:len(m)-1])
-		return db.GeneratePlaceholder(count)
-	})
-	s = quoteRegex.ReplaceAllStringFunc(s, func(m string) string {
-		if m[0] == '{' {
-			return db.QuoteTableName(m[2 : len(m)-2])
+	s = db.scanSQL(s, func(kind byte, name string) string {
+		switch kind {
+		case ':':
+			placeholders = append(placeholders, name)
+			return db.GeneratePlaceholder(len(placeholders))
+		case '{':
+			return db.QuoteTableName(name)
+		default:
+			return db.QuoteColumnName(name)
 		}
-		return db.QuoteColumnName(m[2 : len(m)-2])
 	})
 	return s, placeholders
 }
 
+// scanSQL scans the given SQL and calls f for each parameter placeholder, table name token and column
+// name token found outside of string literals, quoted identifiers and comments. The "kind" parameter
+// is ':' for "{:name}", '{' for "{{name}}", and '[' for "[[name]]". The token is replaced with the
+// value returned by f. Tokens escaped with a backslash are written without the backslash.
+func (db *DB) scanSQL(s string, f func(kind byte, name string) string) string {
+	var b strings.Builder
+	var syntax SQLSyntax
+	if sb, ok := db.Builder.(SyntaxBuilder); ok {
+		syntax = sb.SQLSyntax()
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '{' || s[i+1] == '['):
+			if _, m := matchSQLToken(s[i+1:]); m != nil {
+				b.WriteString(m[0])
+				i += len(m[0]) + 1
+				continue
+			}
+		case c == '{' || c == '[':
+			if kind, m := matchSQLToken(s[i:]); m != nil {
+				b.WriteString(f(kind, m[1]))
+				i += len(m[0])
+				continue
+			}
+		case c == '\'' || c == '"' || c == '`':
+			n := skipQuoted(s[i:], c, syntax.BackslashEscapes && c != '`')
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		case (c == 'E' || c == 'e') && syntax.EscapeStrings && i+1 < len(s) && s[i+1] == '\'' && (i == 0 || !isWordChar(s[i-1])):
+			n := skipQuoted(s[i+1:], '\'', true) + 1
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		case c == '#' && syntax.HashComments, c == '-' && isDashComment(s[i:], syntax.SpacedDashComments):
+			n := strings.IndexByte(s[i:], '\n')
+			if n < 0 {
+				n = len(s) - i
+			}
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		case c == '/' && strings.HasPrefix(s[i:], "/*"):
+			n := strings.Index(s[i+2:], "*/")
+			if n < 0 {
+				n = len(s) - i
+			} else {
+				n += 4
+			}
+			b.WriteString(s[i : i+n])
+			i += n
+			continue
+		case c == '$' && (i == 0 || !isWordChar(s[i-1])):
+			if tag := dollarRegex.FindString(s[i:]); tag != "" {
+				n := strings.Index(s[i+len(tag):], tag)
+				if n < 0 {
+					n = len(s) - i
+				} else {
+					n += 2 * len(tag)
+				}
+				b.WriteString(s[i : i+n])
+				i += n
+				continue
+			}
+		}
+		b.WriteByte(c)
+		i++
+	}
+	return b.String()
+}
+
+// isDashComment checks if s starts with a "--" comment. If spaced is true, "--" must be followed by
+// a whitespace or control character or by the end of s.
+func isDashComment(s string, spaced bool) bool {
+	if !strings.HasPrefix(s, "--") {
+		return false
+	}
+	return !spaced || len(s) == 2 || s[2] <= ' '
+}
+
+// matchSQLToken matches a placeholder, table name or column name token at the beginning of s.
+// It returns the kind of the token and the submatches, or nil if no token is found.
+func matchSQLToken(s string) (byte, []string) {
+	if m := plRegex.FindStringSubmatch(s); m != nil {
+		return ':', m
+	}
+	if m := tableRegex.FindStringSubmatch(s); m != nil {
+		return '{', m
+	}
+	if m := columnRegex.FindStringSubmatch(s); m != nil {
+		return '[', m
+	}
+	return 0, nil
+}
+
+// skipQuoted returns the length of the quoted string at the beginning of s.
+// A doubled quote character is treated as an escaped quote. If backslash is true,
+// a backslash escapes the character following it.
+func skipQuoted(s string, quote byte, backslash bool) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if backslash {
+				i++
+			}
+		case quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+			} else {
+				return i + 1
+			}
+		}
+	}
+	return len(s)
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
 // newBuilder creates a query builder based on the current driver name.
 func (db *DB) newBuilder(executor Executor) Builder {
 	builderFunc, ok := BuilderFuncMap[db.driverName]
@@ -146,6 +146,38 @@ func TestDB_ProcessSQL(t *testing.T) {
 			"SELECT * FROM \"public\".\"user\" WHERE \"user\".\"id\"=1",
 			nil,
 		},
+		{
+			"string literals",
+			`SELECT '{:x}', 'it''s {{tmpl}}', "[[col]]" FROM t WHERE id={:id}`,
+			`SELECT '{:x}', 'it''s {{tmpl}}', "[[col]]" FROM t WHERE id=?`,
+			`SELECT '{:x}', 'it''s {{tmpl}}', "[[col]]" FROM t WHERE id=$1`,
+			`SELECT '{:x}', 'it''s {{tmpl}}', "[[col]]" FROM t WHERE id=:p1`,
+			[]string{"id"},
+		},
+		{
+			"comments",
+			"SELECT 1 -- {:x}\nFROM t /* {{t}} */ WHERE id={:id}",
+			"SELECT 1 -- {:x}\nFROM t /* {{t}} */ WHERE id=?",
+			"SELECT 1 -- {:x}\nFROM t /* {{t}} */ WHERE id=$1",
+			"SELECT 1 -- {:x}\nFROM t /* {{t}} */ WHERE id=:p1",
+			[]string{"id"},
+		},
+		{
+			"dollar-quoted strings",
+			`SELECT $$ {:x} $$, $body$ [[y]] $body$ FROM t WHERE id={:id}`,
+			`SELECT $$ {:x} $$, $body$ [[y]] $body$ FROM t WHERE id=?`,
+			`SELECT $$ {:x} $$, $body$ [[y]] $body$ FROM t WHERE id=$1`,
+			`SELECT $$ {:x} $$, $body$ [[y]] $body$ FROM t WHERE id=:p1`,
+			[]string{"id"},
+		},
+		{
+			"escaped tokens",
+			`SELECT \{:x}, \{{t}}, \[[c]], \{ FROM t WHERE id={:id}`,
+			`SELECT {:x}, {{t}}, [[c]], \{ FROM t WHERE id=?`,
+			`SELECT {:x}, {{t}}, [[c]], \{ FROM t WHERE id=$1`,
+			`SELECT {:x}, {{t}}, [[c]], \{ FROM t WHERE id=:p1`,
+			[]string{"id"},
+		},
 	}
 
 	mysqlDB := getDB()
@@ -167,6 +199,91 @@ func TestDB_ProcessSQL(t *testing.T) {
 	}
 }
 
+type mysqlLikeBuilder struct {
+	*StandardBuilder
+}
+
+func (b *mysqlLikeBuilder) SQLSyntax() SQLSyntax {
+	return SQLSyntax{BackslashEscapes: true, HashComments: true}
+}
+
+func TestDB_processSQLSyntax(t *testing.T) {
+	tests := []struct {
+		tag     string
+		builder BuilderFunc
+		sql     string
+		result  string
+		params  []string
+	}{
+		{
+			"MySQL backslash escapes",
+			NewMysqlBuilder,
+			`SELECT 'it\'s {:x}', "a\"{:y}" FROM t WHERE id={:id}`,
+			`SELECT 'it\'s {:x}', "a\"{:y}" FROM t WHERE id=?`,
+			[]string{"id"},
+		},
+		{
+			"MySQL hash comments",
+			NewMysqlBuilder,
+			"SELECT 1 # {:x} {{t}}\nFROM t WHERE id={:id}",
+			"SELECT 1 # {:x} {{t}}\nFROM t WHERE id=?",
+			[]string{"id"},
+		},
+		{
+			"MySQL dash comments",
+			NewMysqlBuilder,
+			"SELECT 5--{:x}\nFROM t WHERE id={:id} -- {:y}\n--\tAND {:z}\n--",
+			"SELECT 5--?\nFROM t WHERE id=? -- {:y}\n--\tAND {:z}\n--",
+			[]string{"x", "id"},
+		},
+		{
+			"standard dash comments",
+			NewPgsqlBuilder,
+			"SELECT 5--{:x}\nFROM t WHERE id={:id}",
+			"SELECT 5--{:x}\nFROM t WHERE id=$1",
+			[]string{"id"},
+		},
+		{
+			"PostgreSQL escape strings",
+			NewPgsqlBuilder,
+			`SELECT E'it\'s {:x}' AND id={:id}`,
+			`SELECT E'it\'s {:x}' AND id=$1`,
+			[]string{"id"},
+		},
+		{
+			"PostgreSQL standard strings",
+			NewPgsqlBuilder,
+			`SELECT 'C:\' AND id={:id} AND name='{:x}'`,
+			`SELECT 'C:\' AND id=$1 AND name='{:x}'`,
+			[]string{"id"},
+		},
+		{
+			"no hash comments in PostgreSQL",
+			NewPgsqlBuilder,
+			`SELECT a # {:x} FROM t`,
+			`SELECT a # $1 FROM t`,
+			[]string{"x"},
+		},
+		{
+			"custom builder",
+			func(db *DB, executor Executor) Builder {
+				return &mysqlLikeBuilder{NewStandardBuilder(db, executor).(*StandardBuilder)}
+			},
+			`SELECT 'it\'s {:x}' # {:y}` + "\n" + `FROM t WHERE id={:id}`,
+			`SELECT 'it\'s {:x}' # {:y}` + "\n" + `FROM t WHERE id=?`,
+			[]string{"id"},
+		},
+	}
+
+	for _, test := range tests {
+		db := NewFromDB(nil, "mysql-compatible")
+		db.Builder = test.builder(db, nil)
+		s, params := db.processSQL(test.sql)
+		assert.Equal(t, test.result, s, test.tag)
+		assert.Equal(t, test.params, params, test.tag)
+	}
+}
+
 func TestDB_Begin(t *testing.T) {
 	tests := []struct {
 		makeTx func(db *DB) *Tx
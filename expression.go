@@ -6,8 +6,10 @@ package dbx
 
 import (
 	"fmt"
+	"reflect"
 	"sort"
 	"strings"
+	"sync"
 )
 
 // Expression represents a DB expression that can be embedded in a SQL statement.
@@ -154,6 +156,109 @@ func NotBetween(col string, from, to interface{}) Expression {
 	return &BetweenExp{col, from, to, true}
 }
 
+// Grouping generates a GROUPING expression for the specified columns. It can be selected to
+// distinguish the super-aggregate rows generated by ROLLUP, CUBE or GROUPING SETS.
+// For multiple columns, GROUPING_ID is generated for SQL Server and Oracle.
+func Grouping(cols ...string) Expression {
+	return &GroupingExp{cols}
+}
+
+// SubQueryExp represents a SELECT query that is used as an expression.
+// It is created by calling SelectQuery.AsExp().
+type SubQueryExp struct {
+	query *SelectQuery
+}
+
+// Build converts an expression into a SQL fragment.
+// The SELECT query is built using the builder of the given DB. If the SELECT query cannot be built for the DB,
+// an empty string is returned, and the error is returned by the query the expression is built for.
+func (e *SubQueryExp) Build(db *DB, params Params) string {
+	sql, err := e.query.build(db.Builder, db, params)
+	if err != nil {
+		db.setBuildError(params, err)
+		return ""
+	}
+	return sql
+}
+
+// buildErrors keeps the errors occurring when the expressions of the statements being built for a DB
+// are built. As an expression is given only the DB and the parameters of the statement, the statements
+// are identified by their parameter maps.
+type buildErrors struct {
+	sync.Mutex
+	builds map[uintptr]*buildState
+}
+
+// buildState represents a statement being built.
+type buildState struct {
+	depth int
+	err   error
+}
+
+// beginBuild starts building the statement whose parameters are kept in params.
+// Every call must be paired with a call to endBuild. The calls may be nested when a statement
+// is built as a part of another statement sharing the same parameters.
+func (db *DB) beginBuild(params Params) {
+	if db.builds == nil || params == nil {
+		return
+	}
+	db.builds.Lock()
+	defer db.builds.Unlock()
+	if db.builds.builds == nil {
+		db.builds.builds = map[uintptr]*buildState{}
+	}
+	key := reflect.ValueOf(params).Pointer()
+	state, ok := db.builds.builds[key]
+	if !ok {
+		state = &buildState{}
+		db.builds.builds[key] = state
+	}
+	state.depth++
+}
+
+// endBuild ends building the statement whose parameters are kept in params.
+// It returns the first error occurring when the expressions of the statement were built.
+func (db *DB) endBuild(params Params) error {
+	if db.builds == nil || params == nil {
+		return nil
+	}
+	db.builds.Lock()
+	defer db.builds.Unlock()
+	key := reflect.ValueOf(params).Pointer()
+	state, ok := db.builds.builds[key]
+	if !ok {
+		return nil
+	}
+	if state.depth--; state.depth == 0 {
+		delete(db.builds.builds, key)
+	}
+	return state.err
+}
+
+// setBuildError records the error occurring when an expression of the statement whose parameters
+// are kept in params is built. The error is ignored if no such statement is being built.
+func (db *DB) setBuildError(params Params, err error) {
+	if db.builds == nil || params == nil {
+		return
+	}
+	db.builds.Lock()
+	defer db.builds.Unlock()
+	if state, ok := db.builds.builds[reflect.ValueOf(params).Pointer()]; ok && state.err == nil {
+		state.err = err
+	}
+}
+
+// GroupingExp represents a GROUPING function call which distinguishes the super-aggregate rows
+// generated by ROLLUP, CUBE or GROUPING SETS.
+type GroupingExp struct {
+	cols []string
+}
+
+// Build converts an expression into a SQL fragment.
+func (e *GroupingExp) Build(db *DB, params Params) string {
+	return buildGroupingFunc(db.Builder, db, e.cols)
+}
+
 // Exp represents an expression with a SQL fragment and a list of optional binding parameters.
 type Exp struct {
 	e      string
@@ -191,6 +296,9 @@ func (e HashExp) Build(db *DB, params Params) string {
 		case nil:
 			name = db.QuoteColumnName(name)
 			parts = append(parts, name+" IS NULL")
+		case *SubQueryExp:
+			name = db.QuoteColumnName(name)
+			parts = append(parts, name+" IN ("+value.(*SubQueryExp).Build(db, params)+")")
 		case Expression:
 			if sql := value.(Expression).Build(db, params); sql != "" {
 				parts = append(parts, "("+sql+")")
@@ -269,6 +377,17 @@ func (e *InExp) Build(db *DB, params Params) string {
 		return "0=1"
 	}
 
+	in := "IN"
+	if e.not {
+		in = "NOT IN"
+	}
+	col := db.QuoteColumnName(e.col)
+	if len(e.values) == 1 {
+		if sub, ok := e.values[0].(*SubQueryExp); ok {
+			return fmt.Sprintf("%v %v (%v)", col, in, sub.Build(db, params))
+		}
+	}
+
 	var values []string
 	for _, value := range e.values {
 		switch value.(type) {
@@ -283,17 +402,12 @@ func (e *InExp) Build(db *DB, params Params) string {
 			values = append(values, "{:"+name+"}")
 		}
 	}
-	col := db.QuoteColumnName(e.col)
 	if len(values) == 1 {
 		if e.not {
 			return col + "<>" + values[0]
 		}
 		return col + "=" + values[0]
 	}
-	in := "IN"
-	if e.not {
-		in = "NOT IN"
-	}
 	return fmt.Sprintf("%v %v (%v)", col, in, strings.Join(values, ", "))
 }
 
@@ -398,3 +512,94 @@ func (e *BetweenExp) Build(db *DB, params Params) string {
 	col := db.QuoteColumnName(e.col)
 	return fmt.Sprintf("%v %v {:%v} AND {:%v}", col, between, name1, name2)
 }
+
+// cloneExp returns a deep copy of the given expression so that the copy can be modified without
+// affecting the original one. Expressions of unknown types are returned as is.
+func cloneExp(e Expression) Expression {
+	switch e := e.(type) {
+	case HashExp:
+		h := make(HashExp, len(e))
+		for k, v := range e {
+			h[k] = cloneValue(v)
+		}
+		return h
+	case *Exp:
+		var params Params
+		if e.params != nil {
+			params = Params{}
+			for k, v := range e.params {
+				params[k] = v
+			}
+		}
+		return &Exp{e.e, params}
+	case *NotExp:
+		return &NotExp{cloneExp(e.e)}
+	case *AndOrExp:
+		exps := make([]Expression, len(e.exps))
+		for i, a := range e.exps {
+			exps[i] = cloneExp(a)
+		}
+		return &AndOrExp{exps, e.op}
+	case *InExp:
+		return &InExp{e.col, cloneValue(e.values).([]interface{}), e.not}
+	case *LikeExp:
+		e2 := *e
+		e2.values = append([]string{}, e.values...)
+		e2.escape = append([]string{}, e.escape...)
+		return &e2
+	case *ExistsExp:
+		return &ExistsExp{cloneExp(e.exp), e.not}
+	case *BetweenExp:
+		e2 := *e
+		return &e2
+	case *SubQueryExp:
+		return &SubQueryExp{e.query.Clone()}
+	case *GroupingExp:
+		return &GroupingExp{append([]string{}, e.cols...)}
+	case *usingExp:
+		return &usingExp{append([]string{}, e.cols...)}
+	}
+	return e
+}
+
+// cloneValue returns a deep copy of an expression value which may be an Expression or a list of values.
+func cloneValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case Expression:
+		return cloneExp(v)
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, a := range v {
+			values[i] = cloneValue(a)
+		}
+		return values
+	}
+	return v
+}
+
+// usingExp represents the USING list of columns of a JOIN clause.
+type usingExp struct {
+	cols []string
+}
+
+// Build converts the expression into a list of quoted column names.
+func (e *usingExp) Build(db *DB, params Params) string {
+	cols := make([]string, len(e.cols))
+	for i, col := range e.cols {
+		cols[i] = db.QuoteColumnName(col)
+	}
+	return strings.Join(cols, ", ")
+}
+
+// castExp represents a value bound as a parameter and cast to a column type.
+type castExp struct {
+	value interface{}
+	typ   string
+}
+
+// Build converts the expression into a CAST expression on the parameter bound to the value.
+func (e *castExp) Build(db *DB, params Params) string {
+	name := fmt.Sprintf("p%v", len(params))
+	params[name] = e.value
+	return fmt.Sprintf("CAST({:%v} AS %v)", name, e.typ)
+}
@@ -5,6 +5,7 @@
 package dbx
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -183,3 +184,109 @@ func TestExistsExp(t *testing.T) {
 	e4 := NotExists(NewExp(""))
 	assert.Equal(t, e4.Build(nil, nil), "", `e4.Build()`)
 }
+
+func Test_cloneExp(t *testing.T) {
+	db := getDB()
+
+	exps := []Expression{
+		nil,
+		HashExp{"a": 1, "b": []interface{}{1, 2}, "c": NewExp("c>{:c}", Params{"c": 1})},
+		NewExp("a=1"),
+		Not(HashExp{"a": 1}),
+		And(HashExp{"a": 1}, nil, Or(HashExp{"b": 2})),
+		In("a", 1, NewExp("b")),
+		Like("a", "b").Match(false, true),
+		Exists(NewExp("SELECT 1")),
+		Between("a", 1, 2),
+	}
+	for _, e := range exps {
+		c := cloneExp(e)
+		if e == nil {
+			assert.Nil(t, c)
+			continue
+		}
+		p1, p2 := Params{}, Params{}
+		assert.Equal(t, e.Build(db, p1), c.Build(db, p2))
+		assert.Equal(t, p1, p2)
+	}
+
+	h := HashExp{"a": []interface{}{1, 2}}
+	h2 := cloneExp(h).(HashExp)
+	h2["a"].([]interface{})[0] = 3
+	assert.Equal(t, 1, h["a"].([]interface{})[0])
+
+	l := Like("a", "b")
+	cloneExp(l).(*LikeExp).Match(false, false)
+	assert.True(t, l.left)
+}
+
+func TestSubQueryExp(t *testing.T) {
+	db := getDB()
+	sub := db.Select("id").From("users").Where(HashExp{"status": 1}).Bind(Params{"x": 2})
+
+	params := Params{"a": 1}
+	sql := Exists(sub.AsExp()).Build(db, params)
+	assert.Equal(t, "EXISTS (SELECT `id` FROM `users` WHERE `status`={:p2})", sql)
+	assert.Equal(t, Params{"a": 1, "x": 2, "p2": 1}, params)
+
+	params = Params{}
+	sql = In("user_id", sub.AsExp()).Build(db, params)
+	assert.Equal(t, "`user_id` IN (SELECT `id` FROM `users` WHERE `status`={:p1})", sql)
+	sql = NotIn("user_id", sub.AsExp()).Build(db, Params{})
+	assert.Equal(t, "`user_id` NOT IN (SELECT `id` FROM `users` WHERE `status`={:p1})", sql)
+
+	params = Params{}
+	sql = HashExp{"user_id": sub.AsExp(), "type": 3}.Build(db, params)
+	assert.Equal(t, "`type`={:p0} AND `user_id` IN (SELECT `id` FROM `users` WHERE `status`={:p2})", sql)
+	assert.Equal(t, Params{"p0": 3, "x": 2, "p2": 1}, params)
+
+	pgsqlDB := getDB()
+	pgsqlDB.Builder = NewPgsqlBuilder(pgsqlDB, nil)
+	sql = sub.AsExp().Build(pgsqlDB, Params{})
+	assert.Equal(t, `SELECT "id" FROM "users" WHERE "status"={:p1}`, sql)
+}
+
+func Test_buildErrors(t *testing.T) {
+	db := getDB()
+	e1, e2 := errors.New("e1"), errors.New("e2")
+
+	// errors are ignored unless a statement is being built
+	params := Params{}
+	db.setBuildError(params, e1)
+	assert.Nil(t, db.endBuild(params))
+
+	// the first error is kept until the outermost build ends
+	db.beginBuild(params)
+	db.beginBuild(params)
+	db.setBuildError(params, e1)
+	db.setBuildError(params, e2)
+	assert.Equal(t, e1, db.endBuild(params))
+	assert.Equal(t, e1, db.WithContext(nil).endBuild(params))
+	assert.Empty(t, db.builds.builds)
+	assert.Empty(t, params)
+
+	// statements built at the same time are kept apart
+	p1, p2 := Params{}, Params{}
+	db.beginBuild(p1)
+	db.beginBuild(p2)
+	db.setBuildError(p2, e2)
+	assert.Nil(t, db.endBuild(p1))
+	assert.Equal(t, e2, db.endBuild(p2))
+}
+
+func TestGroupingExp(t *testing.T) {
+	db := getDB()
+	assert.Equal(t, "GROUPING(`year`)", Grouping("year").Build(db, nil))
+	assert.Equal(t, "GROUPING(`year`, `month`)", Grouping("year", "month").Build(db, nil))
+
+	db.Builder = NewMssqlBuilder(db, nil)
+	assert.Equal(t, "GROUPING([year])", Grouping("year").Build(db, nil))
+	assert.Equal(t, "GROUPING_ID([year], [month])", Grouping("year", "month").Build(db, nil))
+
+	db.Builder = NewOciBuilder(db, nil)
+	assert.Equal(t, `GROUPING("year")`, Grouping("year").Build(db, nil))
+	assert.Equal(t, `GROUPING_ID("year", "month")`, Grouping("year", "month").Build(db, nil))
+
+	db.Builder = NewPgsqlBuilder(db, nil)
+	assert.Equal(t, `GROUPING("year", "month")`, Grouping("year", "month").Build(db, nil))
+}
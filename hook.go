@@ -0,0 +1,60 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dbx
+
+import "context"
+
+type (
+	// BeforeInsertHook is the interface that should be implemented by models which need to be processed
+	// before being inserted by ModelQuery.Insert. Returning an error aborts the insertion.
+	BeforeInsertHook interface {
+		BeforeInsert(ctx context.Context, b Builder) error
+	}
+
+	// AfterInsertHook is the interface that should be implemented by models which need to be processed
+	// after being inserted by ModelQuery.Insert. The generated primary key is available in the model.
+	AfterInsertHook interface {
+		AfterInsert(ctx context.Context, b Builder) error
+	}
+
+	// BeforeUpdateHook is the interface that should be implemented by models which need to be processed
+	// before being updated by ModelQuery.Update. Returning an error aborts the update.
+	BeforeUpdateHook interface {
+		BeforeUpdate(ctx context.Context, b Builder) error
+	}
+
+	// AfterUpdateHook is the interface that should be implemented by models which need to be processed
+	// after being updated by ModelQuery.Update.
+	AfterUpdateHook interface {
+		AfterUpdate(ctx context.Context, b Builder) error
+	}
+
+	// BeforeDeleteHook is the interface that should be implemented by models which need to be processed
+	// before being deleted by ModelQuery.Delete. Returning an error aborts the deletion.
+	BeforeDeleteHook interface {
+		BeforeDelete(ctx context.Context, b Builder) error
+	}
+
+	// AfterDeleteHook is the interface that should be implemented by models which need to be processed
+	// after being deleted by ModelQuery.Delete.
+	AfterDeleteHook interface {
+		AfterDelete(ctx context.Context, b Builder) error
+	}
+
+	// AfterFindHook is the interface that should be implemented by models which need to be processed
+	// after being populated with a row of query result by Query.One, Query.All or Rows.ScanStruct. The hook is
+	// called after the result set is closed, so that it may execute other queries using the given builder.
+	AfterFindHook interface {
+		AfterFind(ctx context.Context, b Builder) error
+	}
+)
+
+// hookContext returns the context to be passed to the hook methods, which is context.Background() if ctx is nil.
+func hookContext(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
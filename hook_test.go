@@ -0,0 +1,185 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dbx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type HookedItem struct {
+	ID     int
+	Name   string
+	events []string
+	err    error
+}
+
+func (m HookedItem) TableName() string {
+	return "item"
+}
+
+func (m *HookedItem) record(ctx context.Context, b Builder, event string) error {
+	if ctx == nil || b == nil {
+		return errors.New("missing context or builder")
+	}
+	m.events = append(m.events, event)
+	return m.err
+}
+
+func (m *HookedItem) BeforeInsert(ctx context.Context, b Builder) error {
+	return m.record(ctx, b, "BeforeInsert")
+}
+
+func (m *HookedItem) AfterInsert(ctx context.Context, b Builder) error {
+	return m.record(ctx, b, "AfterInsert")
+}
+
+func (m *HookedItem) BeforeUpdate(ctx context.Context, b Builder) error {
+	return m.record(ctx, b, "BeforeUpdate")
+}
+
+func (m *HookedItem) AfterUpdate(ctx context.Context, b Builder) error {
+	return m.record(ctx, b, "AfterUpdate")
+}
+
+func (m *HookedItem) BeforeDelete(ctx context.Context, b Builder) error {
+	return m.record(ctx, b, "BeforeDelete")
+}
+
+func (m *HookedItem) AfterDelete(ctx context.Context, b Builder) error {
+	return m.record(ctx, b, "AfterDelete")
+}
+
+func (m *HookedItem) AfterFind(ctx context.Context, b Builder) error {
+	return m.record(ctx, b, "AfterFind")
+}
+
+func Test_hookContext(t *testing.T) {
+	assert.Equal(t, context.Background(), hookContext(nil))
+	ctx := context.WithValue(context.Background(), "key", "value")
+	assert.Equal(t, ctx, hookContext(ctx))
+}
+
+func TestRows_afterFind(t *testing.T) {
+	r := &Rows{db: getDB()}
+	var item HookedItem
+	assert.Nil(t, r.afterFind(&item))
+	assert.Equal(t, []string{"AfterFind"}, item.events)
+
+	// structs without hooks
+	assert.Nil(t, r.afterFind(&Item{}))
+
+	item.err = errors.New("abort")
+	assert.Equal(t, item.err, r.afterFind(&item))
+}
+
+func TestRows_callHooks(t *testing.T) {
+	r := &Rows{db: getDB()}
+	e := errors.New("abort")
+	items := []*HookedItem{{ID: 1}, {ID: 2, err: e}, {ID: 3}}
+	for _, item := range items {
+		r.pending = append(r.pending, item)
+	}
+
+	// the hooks are called once, and stop at the first error
+	assert.Equal(t, e, r.callHooks())
+	assert.Equal(t, []string{"AfterFind"}, items[0].events)
+	assert.Equal(t, []string{"AfterFind"}, items[1].events)
+	assert.Nil(t, items[2].events)
+	assert.Nil(t, r.pending)
+	assert.Equal(t, e, r.callHooks())
+	assert.Equal(t, []string{"AfterFind"}, items[0].events)
+}
+
+type TrimmedItem struct {
+	Snapshot
+	ID   int
+	Name string
+}
+
+func (m *TrimmedItem) AfterFind(ctx context.Context, b Builder) error {
+	m.Name = strings.TrimSpace(m.Name)
+	return nil
+}
+
+func TestRows_afterFindSnapshot(t *testing.T) {
+	r := &Rows{db: getDB(), fieldMapFunc: DefaultFieldMapFunc}
+	item := TrimmedItem{ID: 1, Name: " test "}
+	assert.Nil(t, r.afterFind(&item))
+	assert.Equal(t, "test", item.Name)
+
+	// the fields normalized by the hook are not dirty
+	si := getStructInfo(reflect.TypeOf(item), DefaultFieldMapFunc, nil)
+	assert.Nil(t, changedFields(si, reflect.ValueOf(&item).Elem(), &item.Snapshot))
+	item.Name = "changed"
+	assert.Equal(t, []string{"Name"}, changedFields(si, reflect.ValueOf(&item).Elem(), &item.Snapshot))
+}
+
+func TestModelQuery_HookAbort(t *testing.T) {
+	db := getDB()
+	e := errors.New("abort")
+
+	item := HookedItem{ID: 1, err: e}
+	assert.Equal(t, e, db.Model(&item).Insert())
+	assert.Equal(t, e, db.Model(&item).Update())
+	assert.Equal(t, e, db.Model(&item).Delete())
+	assert.Equal(t, []string{"BeforeInsert", "BeforeUpdate", "BeforeDelete"}, item.events)
+}
+
+func TestModelQuery_Hooks(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	item := HookedItem{Name: "test"}
+	if assert.Nil(t, db.Model(&item).Insert()) {
+		assert.Equal(t, 6, item.ID)
+	}
+	assert.Nil(t, db.Model(&item).Update())
+	assert.Nil(t, db.Model(&item).Delete())
+	assert.Equal(t, []string{"BeforeInsert", "AfterInsert", "BeforeUpdate", "AfterUpdate", "BeforeDelete", "AfterDelete"}, item.events)
+
+	var found HookedItem
+	if assert.Nil(t, db.Select().Model(1, &found)) {
+		assert.Equal(t, []string{"AfterFind"}, found.events)
+	}
+
+	// ScanStruct calls the hooks after the rows are closed
+	rows, _ := db.Select().From("item").OrderBy("id").Rows()
+	var scanned []*HookedItem
+	for rows.Next() {
+		item := &HookedItem{}
+		assert.Nil(t, rows.ScanStruct(item))
+		assert.Nil(t, item.events)
+		scanned = append(scanned, item)
+	}
+	assert.Nil(t, rows.Err())
+	if assert.Len(t, scanned, 5) {
+		for _, item := range scanned {
+			assert.Equal(t, []string{"AfterFind"}, item.events)
+		}
+		// the hooks are not called again
+		assert.Nil(t, rows.Close())
+		assert.Equal(t, []string{"AfterFind"}, scanned[0].events)
+	}
+
+	var items []HookedItem
+	if assert.Nil(t, db.Select().OrderBy("id").All(&items)) && assert.Equal(t, 5, len(items)) {
+		for _, item := range items {
+			assert.Equal(t, []string{"AfterFind"}, item.events)
+		}
+	}
+
+	// hooks are given a builder running in the same transaction
+	err := db.Transactional(func(tx *Tx) error {
+		item := HookedItem{Name: "test"}
+		return tx.Model(&item).Insert()
+	})
+	assert.Nil(t, err)
+}
@@ -2,9 +2,12 @@ package dbx
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"time"
 )
 
 type (
@@ -26,7 +29,9 @@ type (
 
 var (
 	MissingPKError   = errors.New("missing primary key declaration")
-	CompositePKError = errors.New("composite primary key is not supported")
+	CompositePKError = errors.New("composite primary key values must be given as a struct, a map or a slice")
+	GeneratedPKError = errors.New("only one primary key column can be generated")
+	UpsertModelError = errors.New("models with a version field or insert and update hooks cannot be upserted")
 )
 
 func NewModelQuery(model interface{}, fieldMapFunc FieldMapFunc, db *DB, builder Builder) *ModelQuery {
@@ -34,7 +39,7 @@ func NewModelQuery(model interface{}, fieldMapFunc FieldMapFunc, db *DB, builder
 		db:      db,
 		ctx:     db.ctx,
 		builder: builder,
-		model:   newStructValue(model, fieldMapFunc, db.TableMapper),
+		model:   newStructValue(model, fieldMapFunc, db.Converters, db.TableMapper),
 	}
 	if q.model == nil {
 		q.lastError = VarTypeError("must be a pointer to a struct representing the model")
@@ -65,61 +70,220 @@ func (q *ModelQuery) Exclude(attrs ...string) *ModelQuery {
 // You may pass a list of the fields to this method to indicate that only those fields should be inserted.
 // You may also call Exclude to exclude some fields from being inserted.
 //
+// The fields tagged with the "readonly" option are never inserted, while the empty fields tagged with the "omitempty"
+// option are not inserted unless they are listed in attrs, so that the column defaults of the database are used.
+// The values of the fields tagged with the "type=name" option are cast to the given column type, for example
+// "type=jsonb" results in "CAST({:p0} AS jsonb)". The values of the fields tagged with the "encrypted" option
+// are encrypted by DB.Cipher.
+//
 // If a model has an empty primary key, it is considered auto-incremental and the corresponding struct
 // field will be filled with the generated primary key value after a successful insertion.
+// The way of generating an empty primary key can be changed using the following options of the db tag:
+//
+//   - "default": the value is generated by the column default of the database (e.g. a UUID default or an identity).
+//   - "sequence=name": the value is taken from the named database sequence.
+//   - "generator=name": the value is generated on the client side by the named generator in DB.KeyGenerators.
+//
+// The values generated by the database are retrieved after the insertion using RETURNING, OUTPUT INSERTED,
+// SCOPE_IDENTITY() or RETURNING INTO, depending on the database being used.
+// For a composite primary key, at most one of the primary key fields can be generated by the database,
+// otherwise GeneratedPKError is returned.
+//
+// The empty fields tagged with the "autoCreateTime" or "autoUpdateTime" option are set to the current time returned
+// by DB.NowFunc. They are inserted even if they are not listed in attrs, unless they are excluded by Exclude.
+//
+// The empty fields other than the primary key tagged with the "default" option are not inserted, so that the column
+// defaults of the database are used. Their values are read back from the inserted row using the primary key.
+//
+// If the model implements BeforeInsertHook or AfterInsertHook, the hook methods are called before and after
+// the insertion, respectively. An error returned by BeforeInsert aborts the insertion.
 func (q *ModelQuery) Insert(attrs ...string) error {
 	if q.lastError != nil {
 		return q.lastError
 	}
-	cols := q.model.columns(attrs, q.exclude)
-	pkName := ""
-	for name, value := range q.model.pk() {
-		if isAutoInc(value) {
-			delete(cols, name)
-			pkName = name
-			break
+	if h, ok := q.model.value.Addr().Interface().(BeforeInsertHook); ok {
+		if err := h.BeforeInsert(hookContext(q.ctx), q.builder); err != nil {
+			return err
 		}
 	}
+	if err := q.insert(attrs); err != nil {
+		return err
+	}
+	q.snapshot()
+	if h, ok := q.model.value.Addr().Interface().(AfterInsertHook); ok {
+		return h.AfterInsert(hookContext(q.ctx), q.builder)
+	}
+	return nil
+}
 
-	if pkName == "" {
-		_, err := q.builder.Insert(q.model.tableName, Params(cols)).WithContext(q.ctx).Execute()
+// insert inserts a row in the table using the struct model associated with this query.
+func (q *ModelQuery) insert(attrs []string) error {
+	cols := q.model.writableColumns(attrs, q.exclude, true)
+	if err := q.touch(cols, false, "autoCreateTime", "autoUpdateTime"); err != nil {
 		return err
 	}
+	var generated *fieldInfo
+	autoInc := false
+	for _, name := range q.model.pkNames {
+		fi := q.model.nameMap[name]
+		value := fi.getValue(q.model.value)
+		if generator, ok := fi.option("generator"); ok {
+			if isEmptyValue(reflect.ValueOf(value)) {
+				value, err := q.generateKey(fi, generator)
+				if err != nil {
+					return err
+				}
+				cols[fi.dbName] = value
+			}
+			continue
+		}
+		if sequence, ok := fi.option("sequence"); ok {
+			if !isEmptyValue(reflect.ValueOf(value)) {
+				continue
+			}
+			exp, err := buildSequenceValue(q.builder, sequence)
+			if err != nil {
+				return err
+			}
+			cols[fi.dbName] = NewExp(exp)
+		} else if _, ok := fi.option("default"); ok {
+			if !isEmptyValue(reflect.ValueOf(value)) {
+				continue
+			}
+			delete(cols, fi.dbName)
+		} else if isAutoInc(value) {
+			delete(cols, fi.dbName)
+			autoInc = true
+		} else {
+			continue
+		}
+		if generated != nil {
+			return GeneratedPKError
+		}
+		generated = fi
+	}
+
+	defaults := q.omitDefaults(cols)
+
+	query := q.builder.Insert(q.model.tableName, q.model.params(cols, q.db)).WithContext(q.ctx)
+	if generated == nil {
+		if _, err := query.Execute(); err != nil {
+			return err
+		}
+		return q.readDefaults(defaults)
+	}
 
-	// handle auto-incremental PK
-	query := q.builder.Insert(q.model.tableName, Params(cols)).WithContext(q.ctx)
-	pkValue, err := insertAndReturnPK(q.db, query, pkName)
+	// handle the primary key generated by the database
+	pkField := generated.getField(q.model.value)
+	pkType := pkField.Type()
+	if pkType.Kind() == reflect.Ptr {
+		pkType = pkType.Elem()
+	}
+	pkValue, err := insertAndReturnPK(q.builder, query, generated.dbName, autoInc, pkType)
 	if err != nil {
 		return err
 	}
+	indirect(pkField).Set(pkValue)
+	return q.readDefaults(defaults)
+}
 
-	pkField := indirect(q.model.dbNameMap[pkName].getField(q.model.value))
-	switch pkField.Kind() {
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		pkField.SetUint(uint64(pkValue))
-	default:
-		pkField.SetInt(pkValue)
+// omitDefaults removes from cols the empty non-primary-key fields tagged with the "default" option,
+// and returns them sorted by their column names.
+func (q *ModelQuery) omitDefaults(cols map[string]interface{}) []*fieldInfo {
+	var fields []*fieldInfo
+	for _, fi := range q.model.nameMap {
+		if _, ok := fi.option("default"); !ok || contains(q.model.pkNames, fi.name) {
+			continue
+		}
+		if value, ok := cols[fi.dbName]; ok && isEmptyValue(reflect.ValueOf(value)) {
+			delete(cols, fi.dbName)
+			fields = append(fields, fi)
+		}
 	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].dbName < fields[j].dbName
+	})
+	return fields
+}
 
-	return nil
+// readDefaults populates the given fields with the column values of the row having the primary key of the model.
+// Nothing is done if the model does not have a primary key to locate the row.
+func (q *ModelQuery) readDefaults(fields []*fieldInfo) error {
+	pk := q.model.pk()
+	if len(fields) == 0 || len(pk) == 0 || hasEmptyPK(pk) {
+		return nil
+	}
+	cols := make([]string, len(fields))
+	refs := make([]interface{}, len(fields))
+	for i, fi := range fields {
+		cols[i] = fi.dbName
+		refs[i] = fi.scanTarget(q.model.value, q.db)
+	}
+	return q.builder.Select(cols...).From(q.model.tableName).Where(HashExp(pk)).WithContext(q.ctx).Row(refs...)
+}
+
+// generateKey generates a primary key value using the named generator and populates it into the primary key field.
+func (q *ModelQuery) generateKey(fi *fieldInfo, generator string) (interface{}, error) {
+	g, ok := q.db.KeyGenerators[generator]
+	if !ok {
+		return nil, fmt.Errorf("key generator %q is not found", generator)
+	}
+	value, err := g()
+	if err != nil {
+		return nil, err
+	}
+
+	field := indirect(fi.getField(q.model.value))
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || !v.Type().ConvertibleTo(field.Type()) {
+		return nil, fmt.Errorf("key generator %q returns a value that cannot be assigned to %v", generator, fi.name)
+	}
+	field.Set(v.Convert(field.Type()))
+	return field.Interface(), nil
 }
 
-func insertAndReturnPK(db *DB, query *Query, pkName string) (int64, error) {
-	if db.DriverName() != "postgres" && db.DriverName() != "pgx" {
-		result, err := query.Execute()
-		if err != nil {
-			return 0, err
+// insertAndReturnPK executes the INSERT query and returns the value of the primary key column generated
+// by the database. The value is of the given type and is retrieved in the way supported by the database.
+// If the builder does not implement ReturningBuilder, the value is retrieved by Result.LastInsertId.
+func insertAndReturnPK(builder Builder, query *Query, pkName string, autoInc bool, t reflect.Type) (reflect.Value, error) {
+	dest := reflect.New(t)
+	if rb, ok := builder.(ReturningBuilder); ok {
+		if ok, err := rb.InsertReturningPK(query, pkName, autoInc, dest.Interface()); ok || err != nil {
+			return dest.Elem(), err
 		}
-		return result.LastInsertId()
 	}
 
-	// specially handle postgres (lib/pq) as it doesn't support LastInsertId
-	returning := fmt.Sprintf(" RETURNING %s", db.QuoteColumnName(pkName))
-	query.sql += returning
-	query.rawSQL += returning
-	var pkValue int64
-	err := query.Row(&pkValue)
-	return pkValue, err
+	if !autoInc {
+		return dest.Elem(), errors.New("the database does not support returning the generated primary key")
+	}
+	if !isIntegerKind(t.Kind()) {
+		return dest.Elem(), errors.New("the database only supports returning auto-incremental integer primary keys")
+	}
+	result, err := query.Execute()
+	if err != nil {
+		return dest.Elem(), err
+	}
+	pkValue, err := result.LastInsertId()
+	if err != nil {
+		return dest.Elem(), err
+	}
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		dest.Elem().SetUint(uint64(pkValue))
+	default:
+		dest.Elem().SetInt(pkValue)
+	}
+	return dest.Elem(), nil
+}
+
+// isIntegerKind checks if a kind is a signed or unsigned integer kind.
+func isIntegerKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
 }
 
 func isAutoInc(value interface{}) bool {
@@ -143,6 +307,21 @@ func isAutoInc(value interface{}) bool {
 // By default, it updates *all* public fields in the table, including those nil or empty ones.
 // You may pass a list of the fields to this method to indicate that only those fields should be updated.
 // You may also call Exclude to exclude some fields from being updated.
+// If the model embeds dbx.Snapshot and a snapshot has been taken, only the fields changed since the snapshot
+// are updated when no fields are given, and no row is updated if none of the fields is changed.
+//
+// The fields tagged with the "readonly" or "insertonly" option are never updated. The "omitempty" and "type" options
+// are handled in the same way as Insert. No row is updated if no field is left to be updated.
+//
+// The fields tagged with the "autoUpdateTime" option are set to the current time returned by DB.NowFunc.
+// They are updated even if they are not listed in attrs, unless they are excluded by Exclude.
+//
+// If the model has an integer field tagged with the "version" option, the row is only updated if its version
+// column still equals the field, and the version column is incremented. The field is incremented after
+// a successful update, while a *StaleObjectError is returned if no row is updated.
+//
+// If the model implements BeforeUpdateHook or AfterUpdateHook, the hook methods are called before and after
+// the update, respectively. An error returned by BeforeUpdate aborts the update.
 func (q *ModelQuery) Update(attrs ...string) error {
 	if q.lastError != nil {
 		return q.lastError
@@ -151,17 +330,304 @@ func (q *ModelQuery) Update(attrs ...string) error {
 	if len(pk) == 0 {
 		return MissingPKError
 	}
+	if h, ok := q.model.value.Addr().Interface().(BeforeUpdateHook); ok {
+		if err := h.BeforeUpdate(hookContext(q.ctx), q.builder); err != nil {
+			return err
+		}
+	}
+	if err := q.update(attrs); err != nil {
+		return err
+	}
+	q.snapshot()
+	if h, ok := q.model.value.Addr().Interface().(AfterUpdateHook); ok {
+		return h.AfterUpdate(hookContext(q.ctx), q.builder)
+	}
+	return nil
+}
 
-	cols := q.model.columns(attrs, q.exclude)
+// update updates the row having the same primary key as the struct model associated with this query.
+func (q *ModelQuery) update(attrs []string) error {
+	pk := q.model.pk()
+	if len(attrs) == 0 {
+		if t, ok := q.model.value.Addr().Interface().(tracker); ok && t.snapshot().values != nil {
+			for _, name := range changedFields(q.model.structInfo, q.model.value, t.snapshot()) {
+				if !contains(q.model.pkNames, name) {
+					attrs = append(attrs, name)
+				}
+			}
+			if len(attrs) == 0 {
+				return nil
+			}
+		}
+	}
+	cols := q.model.writableColumns(attrs, q.exclude, false)
+	if err := q.touch(cols, true, "autoUpdateTime"); err != nil {
+		return err
+	}
 	for name := range pk {
 		delete(cols, name)
 	}
-	_, err := q.builder.Update(q.model.tableName, Params(cols), HashExp(pk)).WithContext(q.ctx).Execute()
-	return err
+	if len(cols) == 0 {
+		return nil
+	}
+
+	fi := q.model.optionField("version")
+	if fi == nil {
+		_, err := q.builder.Update(q.model.tableName, q.model.params(cols, q.db), HashExp(pk)).WithContext(q.ctx).Execute()
+		return err
+	}
+
+	// handle optimistic locking
+	field := indirect(fi.getField(q.model.value))
+	if !isIntegerKind(field.Kind()) {
+		return VarTypeError("version fields must be integers")
+	}
+	version := fi.getValue(q.model.value)
+	next := reflect.New(field.Type()).Elem()
+	if field.Kind() >= reflect.Uint && field.Kind() <= reflect.Uint64 {
+		next.SetUint(field.Uint() + 1)
+	} else {
+		next.SetInt(field.Int() + 1)
+	}
+	cols[fi.dbName] = next.Interface()
+	where := And(HashExp(pk), HashExp{fi.dbName: version})
+	result, err := q.builder.Update(q.model.tableName, q.model.params(cols, q.db), where).WithContext(q.ctx).Execute()
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return &StaleObjectError{q.model.tableName, pk, version}
+	}
+	field.Set(next)
+	return nil
+}
+
+// StaleObjectError is returned by ModelQuery.Update when the model has a field tagged with the "version" option
+// and no row with the same primary key and version is found, which means the row has been updated or deleted
+// since the model was read.
+type StaleObjectError struct {
+	// Table is the name of the table being updated.
+	Table string
+	// PK contains the primary key values indexed by the primary key column names.
+	PK map[string]interface{}
+	// Version is the version of the model being updated.
+	Version interface{}
+}
+
+// Error returns the error message.
+func (e *StaleObjectError) Error() string {
+	return fmt.Sprintf("stale object: the row of %v in table %q has been modified or deleted since version %v", e.PK, e.Table, e.Version)
+}
+
+// Save inserts or updates a row in the table using the struct model associated with this query.
+//
+// If the primary key of the model is empty, or for a composite primary key if any of its fields is empty,
+// the model is inserted by calling Insert. Otherwise, the row having the same primary key is updated by
+// calling Update. The attrs parameter, the excluded fields and the hooks are handled in the same way as
+// Insert and Update.
+func (q *ModelQuery) Save(attrs ...string) error {
+	if q.lastError != nil {
+		return q.lastError
+	}
+	pk := q.model.pk()
+	if len(pk) == 0 {
+		return MissingPKError
+	}
+	if hasEmptyPK(pk) {
+		return q.Insert(attrs...)
+	}
+	return q.Update(attrs...)
+}
+
+// Upsert inserts a row in the table using the struct model associated with this query, or updates the row
+// having the same primary key if it already exists. The statement is generated by Builder.Upsert using
+// the primary key columns as the constraint.
+//
+// The attrs parameter, the excluded fields and the field tag options are handled in the same way as Insert, except
+// that the primary key columns are always included. Note that the fields tagged with "insertonly" are also used
+// to update the existing row because the same values are used for insertion and update. If the primary key
+// of the model is empty (or partially empty), no row can be matched and Upsert behaves like Insert.
+//
+// The fields tagged with "autoUpdateTime" are always set to the current time, while those tagged with
+// "autoCreateTime" are only set if they are empty and are not updated in an existing row.
+// As with Insert, the empty fields other than the primary key tagged with the "default" option are not saved,
+// and their values are read back from the row using the primary key.
+//
+// Because only the database knows whether the row is inserted or updated, neither the hooks nor optimistic
+// locking can be applied. UpsertModelError is returned if the model has a field tagged with the "version" option
+// or implements any of the insert and update hooks. Use Save for such models instead.
+func (q *ModelQuery) Upsert(attrs ...string) error {
+	if q.lastError != nil {
+		return q.lastError
+	}
+	pk := q.model.pk()
+	if len(pk) == 0 {
+		return MissingPKError
+	}
+	if !q.upsertable() {
+		return UpsertModelError
+	}
+	if hasEmptyPK(pk) {
+		return q.Insert(attrs...)
+	}
+
+	cols := q.model.writableColumns(attrs, q.exclude, true)
+	created := map[string]interface{}{}
+	if err := q.touch(created, false, "autoCreateTime"); err != nil {
+		return err
+	}
+	if err := q.touch(cols, true, "autoUpdateTime"); err != nil {
+		return err
+	}
+	defaults := q.omitDefaults(cols)
+	constraints := make([]string, 0, len(pk))
+	for name, value := range pk {
+		cols[name] = value
+		constraints = append(constraints, name)
+	}
+	sort.Strings(constraints)
+	for name, value := range created {
+		cols[name] = value
+	}
+	params := q.model.params(cols, q.db)
+	// the creation time is kept when the row already exists
+	for name := range created {
+		params[name] = insertOnly{params[name]}
+	}
+	if _, err := q.builder.Upsert(q.model.tableName, params, constraints...).WithContext(q.ctx).Execute(); err != nil {
+		return err
+	}
+	if err := q.readDefaults(defaults); err != nil {
+		return err
+	}
+	q.snapshot()
+	return nil
+}
+
+// upsertable checks if the model has neither a version field nor insert and update hooks,
+// which cannot be applied by Upsert.
+func (q *ModelQuery) upsertable() bool {
+	if q.model.optionField("version") != nil {
+		return false
+	}
+	switch q.model.value.Addr().Interface().(type) {
+	case BeforeInsertHook, AfterInsertHook, BeforeUpdateHook, AfterUpdateHook:
+		return false
+	}
+	return true
+}
+
+// hasEmptyPK checks if any of the primary key values is empty.
+func hasEmptyPK(pk map[string]interface{}) bool {
+	for _, value := range pk {
+		if isEmptyValue(reflect.ValueOf(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmptyValue checks if a value is nil or the zero value of its type.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil() || isEmptyValue(v.Elem())
+	}
+	return v.IsZero()
+}
+
+// Snapshot takes a snapshot of the current field values of the model, which must embed dbx.Snapshot.
+// Update then only updates the fields changed since the snapshot if no fields are explicitly given.
+//
+// A snapshot is automatically taken when a model is populated by a query and after it is inserted or updated
+// by ModelQuery, so this method is only needed when a model is populated by other means.
+func (q *ModelQuery) Snapshot() error {
+	if q.lastError != nil {
+		return q.lastError
+	}
+	if _, ok := q.model.value.Addr().Interface().(tracker); !ok {
+		return errors.New("the model must embed dbx.Snapshot to be tracked")
+	}
+	q.snapshot()
+	return nil
+}
+
+// Changed returns the sorted names of the fields of the model whose values are changed since the last snapshot.
+// The model must embed dbx.Snapshot. If no snapshot has been taken, all fields are considered changed.
+func (q *ModelQuery) Changed() ([]string, error) {
+	if q.lastError != nil {
+		return nil, q.lastError
+	}
+	t, ok := q.model.value.Addr().Interface().(tracker)
+	if !ok {
+		return nil, errors.New("the model must embed dbx.Snapshot to be tracked")
+	}
+	return changedFields(q.model.structInfo, q.model.value, t.snapshot()), nil
+}
+
+// snapshot takes a snapshot of the model if it embeds Snapshot.
+func (q *ModelQuery) snapshot() {
+	takeSnapshot(q.model.structInfo, q.model.value)
 }
 
 // Delete deletes a row in the table using the primary key specified by the struct model associated with this query.
+//
+// If the model has a field tagged with the "softDelete" option, the row is soft deleted instead by setting the field
+// to the current time returned by DB.NowFunc. Call ForceDelete to delete the row from the table in this case.
+//
+// If the model implements BeforeDeleteHook or AfterDeleteHook, the hook methods are called before and after
+// the deletion, respectively. An error returned by BeforeDelete aborts the deletion.
 func (q *ModelQuery) Delete() error {
+	return q.delete(false)
+}
+
+// ForceDelete deletes a row in the table using the primary key specified by the struct model associated with
+// this query, even if the model is soft-deletable. The hooks are called in the same way as Delete.
+func (q *ModelQuery) ForceDelete() error {
+	return q.delete(true)
+}
+
+// delete deletes or soft deletes the row having the same primary key as the struct model associated with this query.
+func (q *ModelQuery) delete(force bool) error {
+	if q.lastError != nil {
+		return q.lastError
+	}
+	pk := q.model.pk()
+	if len(pk) == 0 {
+		return MissingPKError
+	}
+	if h, ok := q.model.value.Addr().Interface().(BeforeDeleteHook); ok {
+		if err := h.BeforeDelete(hookContext(q.ctx), q.builder); err != nil {
+			return err
+		}
+	}
+	var query *Query
+	if fi := q.model.optionField("softDelete"); fi != nil && !force {
+		if err := setTime(indirect(fi.getField(q.model.value)), q.now()); err != nil {
+			return err
+		}
+		cols := Params{fi.dbName: fi.getValue(q.model.value)}
+		query = q.builder.Update(q.model.tableName, cols, HashExp(pk))
+	} else {
+		query = q.builder.Delete(q.model.tableName, HashExp(pk))
+	}
+	if _, err := query.WithContext(q.ctx).Execute(); err != nil {
+		return err
+	}
+	if h, ok := q.model.value.Addr().Interface().(AfterDeleteHook); ok {
+		return h.AfterDelete(hookContext(q.ctx), q.builder)
+	}
+	return nil
+}
+
+// Restore restores the soft-deleted row having the same primary key as the struct model associated with this query
+// by clearing the field tagged with the "softDelete" option. The field is set to NULL if it is a nullable time,
+// or to its zero value otherwise. An error is returned if the model is not soft-deletable.
+func (q *ModelQuery) Restore() error {
 	if q.lastError != nil {
 		return q.lastError
 	}
@@ -169,6 +635,75 @@ func (q *ModelQuery) Delete() error {
 	if len(pk) == 0 {
 		return MissingPKError
 	}
-	_, err := q.builder.Delete(q.model.tableName, HashExp(pk)).WithContext(q.ctx).Execute()
+	fi := q.model.optionField("softDelete")
+	if fi == nil {
+		return errors.New("the model is not soft-deletable")
+	}
+	field := fi.getField(q.model.value)
+	field.Set(reflect.Zero(field.Type()))
+	cols := Params{fi.dbName: fi.getValue(q.model.value)}
+	_, err := q.builder.Update(q.model.tableName, cols, HashExp(pk)).WithContext(q.ctx).Execute()
 	return err
 }
+
+// touch fills the fields tagged with any of the given timestamp options with the current time returned by
+// DB.NowFunc, and adds them to cols even if they are not listed in the attributes to be saved.
+// Unless force is true, only empty fields are filled. Excluded fields are neither filled nor added.
+func (q *ModelQuery) touch(cols map[string]interface{}, force bool, options ...string) error {
+	var now time.Time
+	for _, fi := range q.model.nameMap {
+		if !fi.hasOption(options...) || fi.hasOption("readonly") || contains(q.exclude, fi.name) {
+			continue
+		}
+		if force || isEmptyValue(reflect.ValueOf(fi.getValue(q.model.value))) {
+			if now.IsZero() {
+				now = q.now()
+			}
+			if err := setTime(indirect(fi.getField(q.model.value)), now); err != nil {
+				return err
+			}
+		}
+		cols[fi.dbName] = fi.getValue(q.model.value)
+	}
+	return nil
+}
+
+// now returns the current time given by DB.NowFunc.
+func (q *ModelQuery) now() time.Time {
+	if q.db.NowFunc != nil {
+		return q.db.NowFunc()
+	}
+	return time.Now()
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	nullTimeType = reflect.TypeOf(sql.NullTime{})
+)
+
+// setTime sets a time into a field of type time.Time or sql.NullTime, or an integer field as a Unix timestamp.
+func setTime(v reflect.Value, t time.Time) error {
+	switch {
+	case v.Type() == timeType:
+		v.Set(reflect.ValueOf(t))
+	case v.Type() == nullTimeType:
+		v.Set(reflect.ValueOf(sql.NullTime{Time: t, Valid: true}))
+	case v.Kind() >= reflect.Int && v.Kind() <= reflect.Int64:
+		v.SetInt(t.Unix())
+	case v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uint64:
+		v.SetUint(uint64(t.Unix()))
+	default:
+		return VarTypeError("automatic timestamp fields must be time.Time, sql.NullTime or integers")
+	}
+	return nil
+}
+
+// contains checks if a string is in a list of strings.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package dbx
 import (
 	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -12,6 +13,17 @@ type Item struct {
 	Name string
 }
 
+type OrderItem struct {
+	OrderID  int `db:"pk"`
+	ItemID   int `db:"pk"`
+	Quantity int
+	Subtotal float64
+}
+
+func (m OrderItem) TableName() string {
+	return "order_item"
+}
+
 func TestModelQuery_Insert(t *testing.T) {
 	db := getPreparedDB()
 	defer db.Close()
@@ -236,3 +248,711 @@ func TestModelQuery_Delete(t *testing.T) {
 	var a int
 	assert.NotNil(t, db.Model(&a).Delete())
 }
+
+func TestModelQuery_Save(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	{
+		// saving a new model
+		customer := Customer{
+			Name:  "test",
+			Email: "test@example.com",
+		}
+		err := db.Model(&customer).Save()
+		if assert.Nil(t, err) {
+			assert.Equal(t, 4, customer.ID)
+		}
+	}
+
+	{
+		// saving an existing model
+		customer := Customer{
+			ID:    2,
+			Name:  "test2",
+			Email: "test2@example.com",
+		}
+		err := db.Model(&customer).Save("Name")
+		if assert.Nil(t, err) {
+			var c Customer
+			db.Select().From("customer").Where(HashExp{"ID": 2}).One(&c)
+			assert.Equal(t, "test2", c.Name)
+			assert.Equal(t, "user2@example.com", c.Email)
+		}
+	}
+
+	{
+		// saving without primary keys
+		item2 := Item{
+			Name: "test",
+		}
+		assert.Equal(t, MissingPKError, db.Model(&item2).Save())
+	}
+
+	var a int
+	assert.NotNil(t, db.Model(&a).Save())
+}
+
+type DefaultCustomer struct {
+	ID     int
+	Email  string
+	Status int `db:"status,default"`
+}
+
+func (m DefaultCustomer) TableName() string {
+	return "customer"
+}
+
+func TestModelQuery_Upsert(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	{
+		// upserting an existing row
+		customer := Customer{
+			ID:     2,
+			Name:   "test",
+			Email:  "test@example.com",
+			Status: 3,
+		}
+		err := db.Model(&customer).Upsert("Name", "Email")
+		if assert.Nil(t, err) {
+			var c Customer
+			db.Select().From("customer").Where(HashExp{"ID": 2}).One(&c)
+			assert.Equal(t, "test", c.Name)
+			assert.Equal(t, "test@example.com", c.Email)
+			assert.Equal(t, 1, c.Status)
+		}
+	}
+
+	{
+		// upserting a new row
+		customer := Customer{
+			ID:    100,
+			Name:  "test",
+			Email: "test100@example.com",
+		}
+		err := db.Model(&customer).Upsert()
+		if assert.Nil(t, err) {
+			var c Customer
+			err := db.Select().From("customer").Where(HashExp{"ID": 100}).One(&c)
+			assert.Nil(t, err)
+			assert.Equal(t, "test100@example.com", c.Email)
+		}
+	}
+
+	{
+		// upserting with an empty primary key
+		customer := Customer{
+			Name:  "test",
+			Email: "test101@example.com",
+		}
+		err := db.Model(&customer).Upsert()
+		if assert.Nil(t, err) {
+			assert.Equal(t, 101, customer.ID)
+		}
+	}
+
+	{
+		// upserting without primary keys
+		item2 := Item{
+			Name: "test",
+		}
+		assert.Equal(t, MissingPKError, db.Model(&item2).Upsert())
+	}
+
+	{
+		// the empty default fields are neither inserted nor updated, but read back from the row
+		customer := DefaultCustomer{ID: 2, Email: "test2@example.com"}
+		if assert.Nil(t, db.Model(&customer).Upsert()) {
+			assert.Equal(t, 1, customer.Status)
+		}
+		customer = DefaultCustomer{ID: 200, Email: "test200@example.com"}
+		if assert.Nil(t, db.Model(&customer).Upsert()) {
+			assert.Equal(t, 0, customer.Status)
+			var c Customer
+			if assert.Nil(t, db.Select().Model(200, &c)) {
+				assert.Equal(t, "test200@example.com", c.Email)
+			}
+		}
+	}
+}
+
+func TestModelQuery_UnsupportedUpsert(t *testing.T) {
+	db := getDB()
+
+	// hooks cannot be called because it is unknown whether the row is inserted or updated
+	item := HookedItem{ID: 1, Name: "test"}
+	assert.Equal(t, UpsertModelError, db.Model(&item).Upsert())
+	assert.Nil(t, item.events)
+
+	// optimistic locking cannot be applied to the updated row
+	type Document struct {
+		ID      int
+		Version int `db:"version,version"`
+	}
+	assert.Equal(t, UpsertModelError, db.Model(&Document{ID: 1}).Upsert())
+	assert.Equal(t, UpsertModelError, db.Model(&Document{}).Upsert())
+}
+
+func TestModelQuery_CreatedTimeOnUpsert(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	now := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	db.NowFunc = func() time.Time {
+		return now
+	}
+
+	type User struct {
+		ID      int
+		Email   string
+		Created time.Time `db:"created,autoCreateTime"`
+		Updated time.Time `db:"updated,autoUpdateTime"`
+	}
+
+	// the creation time is inserted with a new row
+	user := User{ID: 100, Email: "test@example.com"}
+	if assert.Nil(t, db.Model(&user).Upsert()) {
+		var u User
+		if assert.Nil(t, db.Select().Model(100, &u)) {
+			assert.Equal(t, "2020-01-02", u.Created.Format("2006-01-02"))
+			assert.Equal(t, "2020-01-02", u.Updated.Format("2006-01-02"))
+		}
+	}
+
+	// the creation time of an existing row is kept
+	now = now.AddDate(0, 0, 1)
+	user = User{ID: 100, Email: "test2@example.com"}
+	if assert.Nil(t, db.Model(&user).Upsert()) {
+		var u User
+		if assert.Nil(t, db.Select().Model(100, &u)) {
+			assert.Equal(t, "test2@example.com", u.Email)
+			assert.Equal(t, "2020-01-02", u.Created.Format("2006-01-02"))
+			assert.Equal(t, "2020-01-03", u.Updated.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestModelQuery_ZeroTimeSoftDelete(t *testing.T) {
+	getPreparedDB().Close()
+	// MySQL stores the zero time as the zero date, which is rejected by the strict SQL modes
+	db, err := Open("mysql", TestDSN+"&sql_mode=%27%27")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer db.Close()
+
+	now := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	db.NowFunc = func() time.Time {
+		return now
+	}
+
+	type User struct {
+		ID      int
+		Email   string
+		Updated time.Time `db:"updated,softDelete"`
+	}
+
+	user := User{Email: "test@example.com"}
+	if !assert.Nil(t, db.Model(&user).Insert()) {
+		return
+	}
+	var u User
+	assert.Nil(t, db.Select().Model(user.ID, &u))
+
+	if assert.Nil(t, db.Model(&user).Delete()) {
+		assert.Equal(t, now, user.Updated)
+		assert.Equal(t, sql.ErrNoRows, db.Select().Model(user.ID, &u))
+		if assert.Nil(t, db.Select().OnlyDeleted().Model(user.ID, &u)) {
+			assert.Equal(t, "2020-01-02", u.Updated.Format("2006-01-02"))
+		}
+	}
+
+	// the zero time marks the rows not deleted
+	if assert.Nil(t, db.Model(&user).Restore()) {
+		assert.True(t, user.Updated.IsZero())
+		u = User{}
+		if assert.Nil(t, db.Select().Model(user.ID, &u)) {
+			assert.Equal(t, "test@example.com", u.Email)
+			assert.True(t, u.Updated.IsZero())
+		}
+	}
+}
+
+func TestModelQuery_DefaultColumns(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	type Post struct {
+		ID      int `db:"pk,id"`
+		Title   string
+		Status  int       `db:"status,default"`
+		Created time.Time `db:"created,default"`
+	}
+
+	// the empty default columns are left out and read back by the primary key
+	post := Post{ID: 1, Title: "test"}
+	if assert.Nil(t, db.Model(&post).Insert()) {
+		assert.Equal(t, 1, post.Status)
+		assert.False(t, post.Created.IsZero())
+	}
+
+	// the non-empty default columns are saved
+	post = Post{ID: 2, Title: "test2", Status: 3}
+	if assert.Nil(t, db.Model(&post).Insert()) {
+		var p Post
+		if assert.Nil(t, db.Select().Model(2, &p)) {
+			assert.Equal(t, 3, p.Status)
+			assert.Equal(t, p.Created, post.Created)
+		}
+	}
+
+	// the row cannot be located without the primary key
+	post = Post{Title: "test"}
+	q := db.Model(&post)
+	fields := q.omitDefaults(map[string]interface{}{"title": "test", "status": 0})
+	if assert.Len(t, fields, 1) {
+		assert.Equal(t, "status", fields[0].dbName)
+	}
+	assert.Nil(t, q.readDefaults(fields))
+	assert.Equal(t, 0, post.Status)
+}
+
+func Test_hasEmptyPK(t *testing.T) {
+	id, name := 0, ""
+	assert.True(t, hasEmptyPK(map[string]interface{}{"id": 0}))
+	assert.True(t, hasEmptyPK(map[string]interface{}{"id": &id, "name": &name}))
+	assert.True(t, hasEmptyPK(map[string]interface{}{"id": (*int)(nil)}))
+	assert.True(t, hasEmptyPK(map[string]interface{}{"id": sql.NullInt64{}}))
+	assert.True(t, hasEmptyPK(map[string]interface{}{"id": 0, "name": "a"}))
+	assert.False(t, hasEmptyPK(map[string]interface{}{"id": 1, "name": "a"}))
+	assert.False(t, hasEmptyPK(map[string]interface{}{"code": "a"}))
+}
+
+func TestModelQuery_CompositePK(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	{
+		// inserting
+		orderItem := OrderItem{OrderID: 3, ItemID: 1, Quantity: 2, Subtotal: 60}
+		err := db.Model(&orderItem).Insert()
+		if assert.Nil(t, err) {
+			var m OrderItem
+			err := db.Select().Model([]interface{}{3, 1}, &m)
+			if assert.Nil(t, err) {
+				assert.Equal(t, orderItem, m)
+			}
+		}
+	}
+
+	{
+		// updating
+		orderItem := OrderItem{OrderID: 1, ItemID: 2, Quantity: 5, Subtotal: 100}
+		err := db.Model(&orderItem).Update("Quantity")
+		if assert.Nil(t, err) {
+			var m OrderItem
+			err := db.Select().Model(map[string]interface{}{"order_id": 1, "item_id": 2}, &m)
+			if assert.Nil(t, err) {
+				assert.Equal(t, 5, m.Quantity)
+				assert.Equal(t, float64(40), m.Subtotal)
+			}
+			var m2 OrderItem
+			db.Select().Model(OrderItem{OrderID: 1, ItemID: 1}, &m2)
+			assert.Equal(t, 1, m2.Quantity)
+		}
+	}
+
+	{
+		// deleting
+		orderItem := OrderItem{OrderID: 2, ItemID: 4}
+		err := db.Model(&orderItem).Delete()
+		if assert.Nil(t, err) {
+			var m OrderItem
+			err := db.Select().Model(orderItem, &m)
+			assert.Equal(t, sql.ErrNoRows, err)
+			err = db.Select().Model(OrderItem{OrderID: 2, ItemID: 5}, &m)
+			assert.Nil(t, err)
+		}
+	}
+
+	{
+		// inserting with more than one generated primary key field
+		orderItem := OrderItem{Quantity: 1}
+		assert.Equal(t, GeneratedPKError, db.Model(&orderItem).Insert())
+	}
+}
+
+func TestModelQuery_GeneratedPK(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	db.KeyGenerators = map[string]KeyGenerator{
+		"next": func() (interface{}, error) {
+			return 100, nil
+		},
+	}
+
+	{
+		// inserting with a client-side generator
+		item := struct {
+			ID   int64 `db:"pk,id,generator=next"`
+			Name string
+		}{Name: "test"}
+		err := db.Model(&item).Insert()
+		if assert.Nil(t, err) {
+			assert.Equal(t, int64(100), item.ID)
+			var name string
+			db.Select("name").From("item").Where(HashExp{"id": 100}).Row(&name)
+			assert.Equal(t, "test", name)
+		}
+	}
+
+	{
+		// inserting with a pointer-typed auto-incremental key
+		item := struct {
+			ID   *uint `db:"pk,id"`
+			Name string
+		}{Name: "test2"}
+		err := db.Model(&item).Insert()
+		if assert.Nil(t, err) && assert.NotNil(t, item.ID) {
+			assert.Equal(t, uint(101), *item.ID)
+		}
+	}
+}
+
+func TestModelQuery_GeneratedPKErrors(t *testing.T) {
+	db := getDB()
+
+	{
+		// MySQL cannot return a key generated by a column default
+		item := struct {
+			ID   string `db:"pk,id,default"`
+			Name string
+		}{}
+		err := db.Model(&item).Insert()
+		assert.NotNil(t, err)
+	}
+
+	{
+		// MySQL does not support sequences
+		item := struct {
+			ID   string `db:"pk,id,sequence=item_seq"`
+			Name string
+		}{}
+		err := db.Model(&item).Insert()
+		assert.NotNil(t, err)
+	}
+
+	{
+		// unknown or incompatible generators
+		item := struct {
+			ID   int `db:"pk,id,generator=uuid"`
+			Name string
+		}{}
+		err := db.Model(&item).Insert()
+		assert.NotNil(t, err)
+		db.KeyGenerators = map[string]KeyGenerator{
+			"uuid": func() (interface{}, error) {
+				return "2c1b8d1e-8a0c-4c54-9f24-5bd0b7c3b3f6", nil
+			},
+		}
+		err = db.Model(&item).Insert()
+		assert.NotNil(t, err)
+	}
+}
+
+func TestModelQuery_Timestamps(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	now := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	db.NowFunc = func() time.Time {
+		return now
+	}
+
+	type User struct {
+		ID      int
+		Email   string
+		Created time.Time  `db:"created,autoCreateTime"`
+		Updated *time.Time `db:"updated,autoUpdateTime"`
+	}
+
+	user := User{Email: "test@example.com"}
+	if assert.Nil(t, db.Model(&user).Insert("Email")) {
+		assert.Equal(t, now, user.Created)
+		if assert.NotNil(t, user.Updated) {
+			assert.Equal(t, now, *user.Updated)
+		}
+	}
+
+	now = now.AddDate(0, 0, 1)
+	user.Email = "test2@example.com"
+	if assert.Nil(t, db.Model(&user).Update("Email")) {
+		var u User
+		db.Select().From("user").Where(HashExp{"id": user.ID}).One(&u)
+		assert.Equal(t, "test2@example.com", u.Email)
+		assert.Equal(t, "2020-01-02", u.Created.Format("2006-01-02"))
+		if assert.NotNil(t, u.Updated) {
+			assert.Equal(t, "2020-01-03", u.Updated.Format("2006-01-02"))
+		}
+	}
+}
+
+func TestModelQuery_touch(t *testing.T) {
+	db := getDB()
+	now := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	db.NowFunc = func() time.Time {
+		return now
+	}
+
+	type Post struct {
+		ID        int
+		Title     string
+		CreatedAt int64        `db:"created_at,autoCreateTime"`
+		UpdatedAt sql.NullTime `db:"updated_at,autoUpdateTime"`
+		DeletedAt string       `db:"deleted_at,autoUpdateTime"`
+	}
+
+	post := Post{CreatedAt: 100}
+	q := db.Model(&post)
+	cols := map[string]interface{}{}
+	assert.Nil(t, q.touch(cols, false, "autoCreateTime"))
+	assert.Equal(t, map[string]interface{}{"created_at": int64(100)}, cols)
+	assert.Nil(t, q.touch(cols, true, "autoCreateTime"))
+	assert.Equal(t, map[string]interface{}{"created_at": now.Unix()}, cols)
+
+	cols = map[string]interface{}{}
+	q.Exclude("DeletedAt")
+	assert.Nil(t, q.touch(cols, false, "autoUpdateTime"))
+	assert.Equal(t, map[string]interface{}{"updated_at": sql.NullTime{Time: now, Valid: true}}, cols)
+
+	cols = map[string]interface{}{}
+	assert.NotNil(t, db.Model(&post).touch(cols, true, "autoUpdateTime"))
+}
+
+func TestModelQuery_SoftDelete(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	type User struct {
+		ID      int
+		Email   string
+		Updated *time.Time `db:"updated,softDelete"`
+	}
+
+	var user User
+	if !assert.Nil(t, db.Select().Model(1, &user)) {
+		return
+	}
+
+	// soft deleting
+	if assert.Nil(t, db.Model(&user).Delete()) {
+		assert.NotNil(t, user.Updated)
+		var users []User
+		db.Select().All(&users)
+		assert.Equal(t, 1, len(users))
+		assert.Equal(t, sql.ErrNoRows, db.Select().Model(1, &User{}))
+		users = nil
+		db.Select().OnlyDeleted().All(&users)
+		if assert.Equal(t, 1, len(users)) {
+			assert.Equal(t, 1, users[0].ID)
+		}
+		users = nil
+		db.Select().WithDeleted().All(&users)
+		assert.Equal(t, 2, len(users))
+	}
+
+	// restoring
+	if assert.Nil(t, db.Model(&user).Restore()) {
+		assert.Nil(t, user.Updated)
+		assert.Nil(t, db.Select().Model(1, &User{}))
+	}
+
+	// force deleting
+	if assert.Nil(t, db.Model(&user).ForceDelete()) {
+		var count int
+		db.Select("COUNT(*)").From("user").Row(&count)
+		assert.Equal(t, 1, count)
+	}
+
+	assert.NotNil(t, db.Model(&Customer{ID: 1}).Restore())
+}
+
+func TestModelQuery_Version(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	type Customer struct {
+		ID      int
+		Name    string
+		Email   string
+		Version int `db:"status,version"`
+	}
+
+	var c1, c2 Customer
+	db.Select().Model(1, &c1)
+	db.Select().Model(1, &c2)
+	assert.Equal(t, 1, c1.Version)
+
+	c1.Name = "test1"
+	if assert.Nil(t, db.Model(&c1).Update("Name")) {
+		assert.Equal(t, 2, c1.Version)
+		var c Customer
+		db.Select().Model(1, &c)
+		assert.Equal(t, "test1", c.Name)
+		assert.Equal(t, 2, c.Version)
+	}
+
+	c2.Name = "test2"
+	err := db.Model(&c2).Update("Name")
+	if assert.IsType(t, &StaleObjectError{}, err) {
+		e := err.(*StaleObjectError)
+		assert.Equal(t, "customer", e.Table)
+		assert.Equal(t, map[string]interface{}{"id": 1}, e.PK)
+		assert.Equal(t, 1, e.Version)
+		assert.Equal(t, 1, c2.Version)
+	}
+}
+
+func TestModelQuery_VersionErrors(t *testing.T) {
+	db := getDB()
+
+	item := struct {
+		ID      int
+		Version string `db:"version,version"`
+	}{ID: 1}
+	assert.Equal(t, VarTypeError("version fields must be integers"), db.Model(&item).Update())
+
+	e := &StaleObjectError{"customer", map[string]interface{}{"id": 1}, 2}
+	assert.Equal(t, `stale object: the row of map[id:1] in table "customer" has been modified or deleted since version 2`, e.Error())
+}
+
+func TestModelQuery_Snapshot(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	type Customer struct {
+		Snapshot
+		ID      int
+		Name    string
+		Email   string
+		Status  int
+		Address sql.NullString
+	}
+
+	var c Customer
+	db.Select().Model(2, &c)
+	changed, _ := db.Model(&c).Changed()
+	assert.Empty(t, changed)
+
+	// only the changed field is updated, while the stale email is kept as is in the db
+	db.NewQuery("UPDATE customer SET email='new@example.com' WHERE id=2").Execute()
+	c.Name = "test"
+	changed, _ = db.Model(&c).Changed()
+	assert.Equal(t, []string{"Name"}, changed)
+	if assert.Nil(t, db.Model(&c).Update()) {
+		var c2 Customer
+		db.Select().Model(2, &c2)
+		assert.Equal(t, "test", c2.Name)
+		assert.Equal(t, "new@example.com", c2.Email)
+	}
+	changed, _ = db.Model(&c).Changed()
+	assert.Empty(t, changed)
+
+	// nothing is changed
+	assert.Nil(t, db.Model(&c).Update())
+
+	var customers []Customer
+	db.Select().OrderBy("id").All(&customers)
+	if assert.Len(t, customers, 3) {
+		customers[1].Status = 5
+		changed, _ = db.Model(&customers[1]).Changed()
+		assert.Equal(t, []string{"Status"}, changed)
+	}
+}
+
+func TestModelQuery_Changed(t *testing.T) {
+	db := getDB()
+
+	type Customer struct {
+		Snapshot
+		ID    int
+		Name  string
+		Email string
+		Data  []byte
+	}
+
+	c := Customer{ID: 1, Name: "test", Data: []byte("abc")}
+	changed, err := db.Model(&c).Changed()
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"Data", "Email", "ID", "Name"}, changed)
+	}
+
+	assert.Nil(t, db.Model(&c).Snapshot())
+	changed, _ = db.Model(&c).Changed()
+	assert.Empty(t, changed)
+
+	c.Email = "test@example.com"
+	c.Data[0] = 'x'
+	changed, _ = db.Model(&c).Changed()
+	assert.Equal(t, []string{"Data", "Email"}, changed)
+
+	// a copy of the model keeps the snapshot
+	c2 := c
+	changed, _ = db.Model(&c2).Changed()
+	assert.Equal(t, []string{"Data", "Email"}, changed)
+
+	item := Item{ID2: 1}
+	assert.NotNil(t, db.Model(&item).Snapshot())
+	_, err = db.Model(&item).Changed()
+	assert.NotNil(t, err)
+}
+
+func TestModelQuery_TagOptions(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	type Customer struct {
+		ID      int
+		Name    string
+		Email   string         `db:"email,insertonly"`
+		Status  int            `db:"status,omitempty"`
+		Address sql.NullString `db:"address,readonly"`
+	}
+
+	c := Customer{Name: "test", Email: "test@example.com", Address: sql.NullString{String: "addr", Valid: true}}
+	if assert.Nil(t, db.Model(&c).Insert()) {
+		var c2 Customer
+		db.Select().Model(c.ID, &c2)
+		assert.Equal(t, "test@example.com", c2.Email)
+		assert.Equal(t, 0, c2.Status)
+		assert.False(t, c2.Address.Valid)
+	}
+
+	c.Name = "test2"
+	c.Email = "test2@example.com"
+	if assert.Nil(t, db.Model(&c).Update()) {
+		var c2 Customer
+		db.Select().Model(c.ID, &c2)
+		assert.Equal(t, "test2", c2.Name)
+		assert.Equal(t, "test@example.com", c2.Email)
+	}
+}
+
+func TestModelQuery_ChangedJSON(t *testing.T) {
+	db := getDB()
+
+	type Customer struct {
+		Snapshot
+		ID   int
+		Meta map[string]int `db:"meta,json"`
+	}
+
+	c := Customer{ID: 1, Meta: map[string]int{"a": 1}}
+	assert.Nil(t, db.Model(&c).Snapshot())
+	c.Meta["a"] = 2
+	changed, _ := db.Model(&c).Changed()
+	assert.Equal(t, []string{"Meta"}, changed)
+}
@@ -10,6 +10,7 @@ import (
 	"database/sql/driver"
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
 )
@@ -34,6 +35,7 @@ type Executor interface {
 
 // Query represents a SQL statement to be executed.
 type Query struct {
+	db       *DB
 	executor Executor
 
 	sql, rawSQL  string
@@ -57,12 +59,15 @@ type Query struct {
 	QueryLogFunc QueryLogFunc
 	// ExecLogFunc is called each time when a SQL statement is executed.
 	ExecLogFunc ExecLogFunc
+	// StrictMode specifies the checks performed when populating query results into structs.
+	StrictMode StrictMode
 }
 
 // NewQuery creates a new Query with the given SQL statement.
 func NewQuery(db *DB, executor Executor, sql string) *Query {
 	rawSQL, placeholders := db.processSQL(sql)
 	return &Query{
+		db:           db,
 		executor:     executor,
 		sql:          sql,
 		rawSQL:       rawSQL,
@@ -74,9 +79,23 @@ func NewQuery(db *DB, executor Executor, sql string) *Query {
 		PerfFunc:     db.PerfFunc,
 		QueryLogFunc: db.QueryLogFunc,
 		ExecLogFunc:  db.ExecLogFunc,
+		StrictMode:   db.StrictMode,
 	}
 }
 
+// Clone returns a copy of the query whose parameters can be modified without affecting the original query.
+// The copy is not prepared even if the original query is.
+func (q *Query) Clone() *Query {
+	q2 := *q
+	q2.placeholders = append([]string{}, q.placeholders...)
+	q2.params = Params{}
+	for k, v := range q.params {
+		q2.params[k] = v
+	}
+	q2.stmt = nil
+	return &q2
+}
+
 // SQL returns the original SQL used to create the query.
 // The actual SQL (RawSQL) being executed is obtained by replacing the named
 // parameter placeholders with anonymous ones.
@@ -98,22 +117,31 @@ func (q *Query) WithContext(ctx context.Context) *Query {
 // logSQL returns the SQL statement with parameters being replaced with the actual values.
 // The result is only for logging purpose and should not be used to execute.
 func (q *Query) logSQL() string {
-	s := q.sql
-	for k, v := range q.params {
+	return q.db.scanSQL(q.sql, func(kind byte, name string) string {
+		switch kind {
+		case '{':
+			return "{{" + name + "}}"
+		case '[':
+			return "[[" + name + "]]"
+		}
+		v, ok := q.params[name]
+		if !ok {
+			return "{:" + name + "}"
+		}
+		if _, ok := v.(encryptedValue); ok {
+			// encrypted values are neither encrypted again nor revealed in the log
+			return "{:" + name + "}"
+		}
 		if valuer, ok := v.(driver.Valuer); ok && valuer != nil {
 			v, _ = valuer.Value()
 		}
-		var sv string
 		if str, ok := v.(string); ok {
-			sv = "'" + strings.Replace(str, "'", "''", -1) + "'"
+			return "'" + strings.Replace(str, "'", "''", -1) + "'"
 		} else if bs, ok := v.([]byte); ok {
-			sv = "'" + strings.Replace(string(bs), "'", "''", -1) + "'"
-		} else {
-			sv = fmt.Sprintf("%v", v)
+			return "'" + strings.Replace(string(bs), "'", "''", -1) + "'"
 		}
-		s = strings.Replace(s, "{:"+k+"}", sv, -1)
-	}
-	return s
+		return fmt.Sprintf("%v", v)
+	})
 }
 
 // Params returns the parameters to be bound to the SQL statement represented by this query.
@@ -167,7 +195,7 @@ func (q *Query) Execute() (result sql.Result, err error) {
 	}
 
 	var params []interface{}
-	params, err = replacePlaceholders(q.placeholders, q.params)
+	params, err = replacePlaceholders(q.placeholders, q.params, q.converters())
 	if err != nil {
 		return
 	}
@@ -200,8 +228,10 @@ func (q *Query) Execute() (result sql.Result, err error) {
 	return
 }
 
-// One executes the SQL statement and populates the first row of the result into a struct or NullStringMap.
-// Refer to Rows.ScanStruct() and Rows.ScanMap() for more details on how to specify
+// One executes the SQL statement and populates the first row of the result into a struct, NullStringMap
+// or map[string]interface{}. If a pointer to another type is given, such as *int, the first column of the row
+// is populated into it.
+// Refer to Rows.ScanStruct(), Rows.ScanMap() and Rows.ScanMapNative() for more details on how to specify
 // the variable to be populated.
 // Note that when the query has no rows in the result set, an sql.ErrNoRows will be returned.
 func (q *Query) One(a interface{}) error {
@@ -212,9 +242,11 @@ func (q *Query) One(a interface{}) error {
 	return rows.one(a)
 }
 
-// All executes the SQL statement and populates all the resulting rows into a slice of struct or NullStringMap.
-// The slice must be given as a pointer. Each slice element must be either a struct or a NullStringMap.
-// Refer to Rows.ScanStruct() and Rows.ScanMap() for more details on how each slice element can be.
+// All executes the SQL statement and populates all the resulting rows into a slice of struct, NullStringMap
+// or map[string]interface{}. The slice must be given as a pointer. Each slice element may be a struct,
+// a pointer to a struct, a NullStringMap or a map[string]interface{}. A slice of other types, such as []int,
+// is populated with the first column of the result as done by Column().
+// Refer to Rows.ScanStruct(), Rows.ScanMap() and Rows.ScanMapNative() for more details on how each slice element can be.
 // If the query returns no row, the slice will be an empty slice (not nil).
 func (q *Query) All(slice interface{}) error {
 	rows, err := q.Rows()
@@ -254,7 +286,7 @@ func (q *Query) Rows() (rows *Rows, err error) {
 	}
 
 	var params []interface{}
-	params, err = replacePlaceholders(q.placeholders, q.params)
+	params, err = replacePlaceholders(q.placeholders, q.params, q.converters())
 	if err != nil {
 		return
 	}
@@ -275,7 +307,14 @@ func (q *Query) Rows() (rows *Rows, err error) {
 			rr, err = q.stmt.QueryContext(q.ctx, params...)
 		}
 	}
-	rows = &Rows{rr, q.FieldMapper}
+	rows = &Rows{
+		Rows:         rr,
+		fieldMapFunc: q.FieldMapper,
+		strictMode:   q.StrictMode,
+		ctx:          q.ctx,
+		db:           q.db,
+		executor:     q.executor,
+	}
 
 	if q.QueryLogFunc != nil {
 		q.QueryLogFunc(q.ctx, time.Now().Sub(start), q.logSQL(), rr, err)
@@ -290,18 +329,31 @@ func (q *Query) Rows() (rows *Rows, err error) {
 }
 
 // replacePlaceholders converts a list of named parameters into a list of anonymous parameters.
-func replacePlaceholders(placeholders []string, params Params) ([]interface{}, error) {
+// The parameter values are converted by the converters registered for their types.
+func replacePlaceholders(placeholders []string, params Params, converters map[reflect.Type]Converter) ([]interface{}, error) {
 	if len(placeholders) == 0 {
 		return nil, nil
 	}
 
 	var result []interface{}
 	for _, name := range placeholders {
-		if value, ok := params[name]; ok {
-			result = append(result, value)
-		} else {
+		value, ok := params[name]
+		if !ok {
 			return nil, errors.New("Named parameter not found: " + name)
 		}
+		value, err := convertValue(value, converters)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
 	}
 	return result, nil
 }
+
+// converters returns the converters registered in the DB associated with the query.
+func (q *Query) converters() map[reflect.Type]Converter {
+	if q.db == nil {
+		return nil
+	}
+	return q.db.Converters
+}
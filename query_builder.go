@@ -6,6 +6,7 @@ package dbx
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
@@ -109,10 +110,17 @@ func (q *BaseQueryBuilder) BuildJoin(joins []JoinInfo, params Params) string {
 	parts := []string{}
 	for _, join := range joins {
 		sql := join.Join + " " + q.quoteTableNameAndAlias(join.Table)
+		if using, ok := join.On.(*usingExp); ok {
+			parts = append(parts, sql+" USING ("+using.Build(q.db, params)+")")
+			continue
+		}
 		on := ""
 		if join.On != nil {
 			on = join.On.Build(q.db, params)
 		}
+		if on == "" && join.Join == "LEFT JOIN LATERAL" {
+			on = "TRUE"
+		}
 		if on != "" {
 			sql += " ON " + on
 		}
@@ -190,6 +198,115 @@ func (q *BaseQueryBuilder) BuildUnion(unions []UnionInfo, params Params) string
 	return sql
 }
 
+// buildGrouping generates a GROUP BY clause followed by a grouping construct using the builder if it implements
+// GroupingBuilder, or the standard SQL otherwise.
+func buildGrouping(b Builder, db *DB, cols []string, grouping *GroupingInfo) (string, error) {
+	if err := grouping.validate(); err != nil {
+		return "", err
+	}
+	if gb, ok := b.(GroupingBuilder); ok {
+		return gb.BuildGrouping(cols, grouping)
+	}
+	return buildStandardGrouping(b, db, cols, grouping)
+}
+
+// buildStandardGrouping generates a GROUP BY clause with a grouping construct in the standard SQL syntax.
+func buildStandardGrouping(b Builder, db *DB, cols []string, grouping *GroupingInfo) (string, error) {
+	qb := b.QueryBuilder()
+	sets := make([]string, len(grouping.Sets))
+	for i, set := range grouping.Sets {
+		quoted := make([]string, len(set))
+		for j, col := range set {
+			quoted[j] = db.QuoteColumnName(col)
+		}
+		sets[i] = "(" + strings.Join(quoted, ", ") + ")"
+	}
+	s := grouping.Type + " " + strings.Join(sets, ", ")
+	if grouping.Type == "GROUPING SETS" {
+		s = grouping.Type + " (" + strings.Join(sets, ", ") + ")"
+	}
+	if len(cols) == 0 {
+		return "GROUP BY " + s, nil
+	}
+	return qb.BuildGroupBy(cols) + ", " + s, nil
+}
+
+// buildGroupingFunc generates a GROUPING function call over the given columns using the GroupingBuilder
+// implemented by the builder, if any.
+func buildGroupingFunc(b Builder, db *DB, cols []string) string {
+	if gb, ok := b.(GroupingBuilder); ok {
+		return gb.BuildGroupingFunc(cols)
+	}
+	return buildFuncCall(db, "GROUPING", cols)
+}
+
+// buildFuncCall generates a call to the named function with the given columns as the arguments.
+func buildFuncCall(db *DB, fn string, cols []string) string {
+	quoted := make([]string, len(cols))
+	for i, col := range cols {
+		quoted[i] = db.QuoteColumnName(col)
+	}
+	return fn + "(" + strings.Join(quoted, ", ") + ")"
+}
+
+// buildExists generates a SELECT statement checking whether the given SELECT statement returns any row
+// using the builder if it implements ExistsBuilder, or the standard SQL otherwise.
+func buildExists(b Builder, sql string) string {
+	if eb, ok := b.(ExistsBuilder); ok {
+		return eb.BuildExists(sql)
+	}
+	return "SELECT EXISTS(" + sql + ")"
+}
+
+// buildDistinctOn generates a DISTINCT ON option using the builder if it implements DistinctOnBuilder.
+func buildDistinctOn(b Builder, cols []string) (string, error) {
+	if db, ok := b.(DistinctOnBuilder); ok {
+		return db.BuildDistinctOn(cols)
+	}
+	return "", errors.New("DISTINCT ON is not supported")
+}
+
+// buildIndexHint generates an index hint using the builder if it implements IndexHintBuilder.
+func buildIndexHint(b Builder, hint IndexHint) (string, error) {
+	if ib, ok := b.(IndexHintBuilder); ok {
+		return ib.BuildIndexHint(hint)
+	}
+	return "", errors.New("index hints are not supported")
+}
+
+// buildJoinType generates the join operator using the builder if it implements JoinTypeBuilder.
+// Otherwise the join operator is used as is.
+func buildJoinType(b Builder, join JoinInfo) (string, error) {
+	if jb, ok := b.(JoinTypeBuilder); ok {
+		return jb.BuildJoinType(join)
+	}
+	return join.Join, nil
+}
+
+// buildSequenceValue generates an expression taking the next value from the named sequence using
+// the builder if it implements SequenceBuilder.
+func buildSequenceValue(b Builder, name string) (string, error) {
+	if sb, ok := b.(SequenceBuilder); ok {
+		return sb.BuildSequenceValue(name)
+	}
+	return "", errors.New("sequences are not supported")
+}
+
+// buildApplyJoinType generates the join operator for databases which use CROSS APPLY and OUTER APPLY
+// in place of lateral joins.
+func buildApplyJoinType(join JoinInfo) (string, error) {
+	switch join.Join {
+	case "CROSS JOIN LATERAL":
+		return "CROSS APPLY", nil
+	case "LEFT JOIN LATERAL":
+		if join.On != nil {
+			return "", errors.New("OUTER APPLY does not support a join condition")
+		}
+		return "OUTER APPLY", nil
+	}
+	return join.Join, nil
+}
+
 var orderRegex = regexp.MustCompile(`\s+((?i)ASC|DESC)$`)
 
 // BuildOrderBy generates the ORDER BY clause.
@@ -235,10 +352,15 @@ func (q *BaseQueryBuilder) BuildLimit(limit int64, offset int64) string {
 }
 
 func (q *BaseQueryBuilder) quoteTableNameAndAlias(table string) string {
+	return quoteTableNameAndAlias(q.db, table)
+}
+
+// quoteTableNameAndAlias quotes a table name which may be followed by an alias.
+func quoteTableNameAndAlias(db *DB, table string) string {
 	matches := selectRegex.FindStringSubmatch(table)
 	if len(matches) == 0 {
-		return q.db.QuoteTableName(table)
+		return db.QuoteTableName(table)
 	}
 	table = table[:len(table)-len(matches[0])]
-	return q.db.QuoteTableName(table) + " " + q.db.QuoteSimpleTableName(matches[1])
+	return db.QuoteTableName(table) + " " + db.QuoteSimpleTableName(matches[1])
 }
@@ -71,6 +71,24 @@ func TestQB_BuildGroupBy(t *testing.T) {
 	}
 }
 
+func TestQB_BuildGrouping(t *testing.T) {
+	db := getDB()
+	db.Builder = NewStandardBuilder(db, nil)
+
+	sql, err := buildGrouping(db.Builder, db, nil, &GroupingInfo{"ROLLUP", [][]string{{"year", "month"}}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, `GROUP BY ROLLUP ("year", "month")`, sql)
+	}
+	sql, err = buildGrouping(db.Builder, db, []string{"region"}, &GroupingInfo{"CUBE", [][]string{{"year", "month"}}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, `GROUP BY "region", CUBE ("year", "month")`, sql)
+	}
+	sql, err = buildGrouping(db.Builder, db, nil, &GroupingInfo{"GROUPING SETS", [][]string{{"year", "month"}, {"year"}, {}}})
+	if assert.Nil(t, err) {
+		assert.Equal(t, `GROUP BY GROUPING SETS (("year", "month"), ("year"), ())`, sql)
+	}
+}
+
 func TestQB_BuildWhere(t *testing.T) {
 	tests := []struct {
 		exp      Expression
@@ -196,6 +214,16 @@ func TestQB_BuildJoin(t *testing.T) {
 	sql = qb.BuildJoin([]JoinInfo{ji, ji2}, nil)
 	expected = "INNER JOIN `users` LEFT JOIN `posts`"
 	assert.Equal(t, sql, expected, "BuildJoin@3")
+
+	ji = JoinInfo{"INNER JOIN", "posts p", &usingExp{[]string{"user_id", "type"}}}
+	sql = qb.BuildJoin([]JoinInfo{ji}, nil)
+	expected = "INNER JOIN `posts` `p` USING (`user_id`, `type`)"
+	assert.Equal(t, sql, expected, "BuildJoin@4")
+
+	ji = JoinInfo{"LEFT JOIN LATERAL", "(SELECT * FROM posts) p", nil}
+	sql = qb.BuildJoin([]JoinInfo{ji}, nil)
+	expected = "LEFT JOIN LATERAL (SELECT * FROM posts) `p` ON TRUE"
+	assert.Equal(t, sql, expected, "BuildJoin@5")
 }
 
 func TestQB_BuildUnion(t *testing.T) {
@@ -226,3 +254,10 @@ func TestQB_BuildUnion(t *testing.T) {
 	expected = "UNION ALL (SELECT names) UNION (SELECT ages)"
 	assert.Equal(t, sql, expected, "BuildUnion@4")
 }
+
+func TestQB_BuildExists(t *testing.T) {
+	db := getDB()
+	db.Builder = NewStandardBuilder(db, nil)
+	sql := buildExists(db.Builder, "SELECT * FROM users")
+	assert.Equal(t, "SELECT EXISTS(SELECT * FROM users)", sql)
+}
@@ -7,6 +7,7 @@ package dbx
 import (
 	ss "database/sql"
 	"encoding/json"
+	"reflect"
 	"testing"
 	"time"
 
@@ -30,6 +31,17 @@ func TestNewQuery(t *testing.T) {
 	assert.Equal(t, len(q.Params()), 1, "len(q.Params())@2")
 }
 
+func TestQuery_Clone(t *testing.T) {
+	db := getDB()
+	q := db.NewQuery("SELECT * FROM users WHERE id={:id}").Bind(Params{"id": 1})
+	q2 := q.Clone()
+	q2.Bind(Params{"id": 2})
+	assert.Equal(t, Params{"id": 1}, q.Params())
+	assert.Equal(t, Params{"id": 2}, q2.Params())
+	assert.Equal(t, q.rawSQL, q2.rawSQL)
+	assert.Equal(t, q.placeholders, q2.placeholders)
+}
+
 func TestQuery_Execute(t *testing.T) {
 	db := getPreparedDB()
 	defer db.Close()
@@ -298,11 +310,264 @@ func TestQuery_Rows(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestQuery_StrictMode(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	type Customer struct {
+		ID    int
+		Name  string
+		Email string
+		Phone string
+	}
+
+	var customers []Customer
+	q := db.NewQuery("SELECT id, name, email, status FROM customer")
+	q.StrictMode = StrictColumns
+	err := q.All(&customers)
+	if assert.IsType(t, &ScanError{}, err) {
+		assert.Equal(t, []string{"status"}, err.(*ScanError).Columns)
+		assert.Empty(t, err.(*ScanError).Fields)
+	}
+
+	var customer Customer
+	q = db.NewQuery("SELECT id, name, email FROM customer WHERE id=1")
+	q.StrictMode = StrictColumns | StrictFields
+	err = q.One(&customer)
+	if assert.IsType(t, &ScanError{}, err) {
+		assert.Empty(t, err.(*ScanError).Columns)
+		assert.Equal(t, []string{"Phone"}, err.(*ScanError).Fields)
+	}
+
+	db.StrictMode = StrictColumns
+	assert.Nil(t, db.Select("id", "name", "email").Model(1, &customer))
+	assert.NotNil(t, db.Select().Model(1, &customer))
+}
+
+func TestRows_checkColumns(t *testing.T) {
+	type Customer struct {
+		ID    int
+		Name  string
+		Email string `db:"email_address"`
+	}
+	ct := reflect.TypeOf(Customer{})
+	si := getStructInfo(ct, DefaultFieldMapFunc, nil)
+
+	r := &Rows{}
+	assert.Nil(t, r.checkColumns(ct, si, []string{"id", "status"}))
+
+	r.strictMode = StrictColumns
+	assert.Nil(t, r.checkColumns(ct, si, []string{"id", "email_address"}))
+	err := r.checkColumns(ct, si, []string{"id", "email", "status"})
+	assert.Equal(t, &ScanError{ct, []string{"email", "status"}, nil}, err)
+	assert.Equal(t, "cannot populate dbx.Customer: columns without fields: email, status", err.Error())
+
+	r.strictMode = StrictFields
+	assert.Nil(t, r.checkColumns(ct, si, []string{"id", "name", "email_address", "status"}))
+	err = r.checkColumns(ct, si, []string{"id", "status"})
+	assert.Equal(t, &ScanError{ct, nil, []string{"Email", "Name"}}, err)
+
+	r.strictMode = StrictColumns | StrictFields
+	err = r.checkColumns(ct, si, []string{"id", "email"})
+	assert.Equal(t, "cannot populate dbx.Customer: columns without fields: email; fields without columns: Email, Name", err.Error())
+}
+
+func TestRows_checkStruct(t *testing.T) {
+	type Customer struct {
+		ID   int
+		Name string
+	}
+	ct := reflect.TypeOf(Customer{})
+	si := getStructInfo(ct, DefaultFieldMapFunc, nil)
+
+	r := &Rows{}
+	assert.Nil(t, r.checkStruct(ct, si, []string{"id", "status"}))
+	assert.Nil(t, r.checked)
+
+	// the result is computed once for the struct type as the columns of the rows never change
+	r.strictMode = StrictColumns
+	err := r.checkStruct(ct, si, []string{"id", "status"})
+	assert.Equal(t, &ScanError{ct, []string{"status"}, nil}, err)
+	assert.Equal(t, err, r.checkStruct(ct, si, []string{"id"}))
+	assert.Len(t, r.checked, 1)
+
+	it := reflect.TypeOf(Item{})
+	assert.Nil(t, r.checkStruct(it, getStructInfo(it, DefaultFieldMapFunc, nil), []string{"id2", "name"}))
+	assert.Len(t, r.checked, 2)
+}
+
+func TestQuery_ValueMap(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	var rows []map[string]interface{}
+	err := db.NewQuery("SELECT id, email, address, status FROM customer ORDER BY id").All(&rows)
+	if assert.Nil(t, err) && assert.Len(t, rows, 3) {
+		assert.Equal(t, int64(1), rows[0]["id"])
+		assert.Equal(t, "user1@example.com", rows[0]["email"])
+		assert.Equal(t, int64(1), rows[0]["status"])
+		assert.Nil(t, rows[1]["address"])
+		assert.Contains(t, rows[1], "address")
+	}
+
+	row := map[string]interface{}{}
+	err = db.NewQuery("SELECT id, total FROM `order` WHERE id=1").One(row)
+	if assert.Nil(t, err) {
+		assert.Equal(t, int64(1), row["id"])
+		assert.Equal(t, "110", row["total"])
+	}
+
+	var params Params
+	err = db.Select("id").From("customer").Where(HashExp{"id": 2}).One(&params)
+	if assert.Nil(t, err) {
+		assert.Equal(t, Params{"id": int64(2)}, params)
+	}
+
+	var invalid map[string]int
+	assert.NotNil(t, db.NewQuery("SELECT id FROM customer").One(&invalid))
+
+	rs, err := db.NewQuery("SELECT id, email FROM customer WHERE id=1").Rows()
+	if assert.Nil(t, err) && assert.True(t, rs.Next()) {
+		values := map[string]interface{}{}
+		assert.NotNil(t, rs.ScanMapNative(nil))
+		if assert.Nil(t, rs.ScanMapNative(values)) {
+			assert.Equal(t, map[string]interface{}{"id": int64(1), "email": "user1@example.com"}, values)
+		}
+		rs.Close()
+	}
+}
+
+type joinedOrder struct {
+	ID       int
+	Total    int
+	Customer struct {
+		Email string
+		Name  string
+	}
+}
+
+func TestQuery_AllSlices(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	var customers []*Customer
+	err := db.Select().OrderBy("id").All(&customers)
+	if assert.Nil(t, err) && assert.Len(t, customers, 3) {
+		assert.Equal(t, 1, customers[0].ID)
+		assert.Equal(t, "user3@example.com", customers[2].Email)
+	}
+
+	var ids []int
+	err = db.Select("id").From("customer").OrderBy("id").All(&ids)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []int{1, 2, 3}, ids)
+	}
+
+	var emails []*string
+	err = db.NewQuery("SELECT email, id FROM customer ORDER BY id").All(&emails)
+	if assert.Nil(t, err) && assert.Len(t, emails, 3) {
+		assert.Equal(t, "user2@example.com", *emails[1])
+	}
+
+	var count int
+	err = db.Select("COUNT(*)").From("customer").One(&count)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 3, count)
+	}
+
+	var customer *Customer
+	err = db.Select().Where(HashExp{"id": 2}).One(&customer)
+	if assert.Nil(t, err) && assert.NotNil(t, customer) {
+		assert.Equal(t, "user2@example.com", customer.Email)
+	}
+
+	var orders []joinedOrder
+	err = db.Select("o.id", "o.total", "c.email AS customer.email", "c.name AS customer.name").
+		From("order o").
+		InnerJoin("customer c", NewExp("c.id=o.customer_id")).
+		OrderBy("o.id").
+		All(&orders)
+	if assert.Nil(t, err) && assert.Len(t, orders, 3) {
+		assert.Equal(t, 1, orders[0].ID)
+		assert.Equal(t, 110, orders[0].Total)
+		assert.Equal(t, "user1@example.com", orders[0].Customer.Email)
+		assert.Equal(t, "user2", orders[1].Customer.Name)
+	}
+
+	var order joinedOrder
+	db.StrictMode = StrictColumns | StrictFields
+	err = db.Select("o.id", "o.total", "c.email AS customer.email", "c.name AS customer.name").
+		From("order o").
+		InnerJoin("customer c", NewExp("c.id=o.customer_id")).
+		Where(HashExp{"o.id": 2}).
+		One(&order)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "user2@example.com", order.Customer.Email)
+	}
+}
+
+func TestRows_isStruct(t *testing.T) {
+	r := &Rows{db: &DB{Converters: testConverters}}
+	assert.True(t, r.isStruct(reflect.TypeOf(Customer{})))
+	assert.True(t, r.isStruct(reflect.TypeOf(&Customer{})))
+	assert.False(t, r.isStruct(reflect.TypeOf(0)))
+	assert.False(t, r.isStruct(reflect.TypeOf(ss.NullString{})))
+	assert.False(t, r.isStruct(reflect.TypeOf(time.Time{})))
+	assert.False(t, r.isStruct(reflect.TypeOf(money{})))
+}
+
+func Test_isValueMap(t *testing.T) {
+	assert.True(t, isValueMap(reflect.TypeOf(map[string]interface{}{})))
+	assert.True(t, isValueMap(reflect.TypeOf(Params{})))
+	assert.False(t, isValueMap(reflect.TypeOf(NullStringMap{})))
+	assert.False(t, isValueMap(reflect.TypeOf(map[int]interface{}{})))
+	assert.False(t, isValueMap(reflect.TypeOf(map[string]Expression{})))
+	assert.False(t, isValueMap(reflect.TypeOf([]interface{}{})))
+}
+
+func Test_columnValue(t *testing.T) {
+	col := &ss.ColumnType{}
+	assert.Equal(t, int64(1), columnValue(col, &ss.NullInt64{Int64: 1, Valid: true}))
+	assert.Nil(t, columnValue(col, &ss.NullInt64{}))
+	assert.Equal(t, 1.5, columnValue(col, &ss.NullFloat64{Float64: 1.5, Valid: true}))
+	assert.Equal(t, false, columnValue(col, &ss.NullBool{Valid: true}))
+	assert.Equal(t, "", columnValue(col, &ss.NullString{Valid: true}))
+	assert.Nil(t, columnValue(col, &ss.NullString{}))
+
+	b := []byte("abc")
+	var value interface{} = b
+	result := columnValue(col, &value)
+	assert.Equal(t, []byte("abc"), result)
+	b[0] = 'x'
+	assert.Equal(t, []byte("abc"), result)
+	value = int64(3)
+	assert.Equal(t, int64(3), columnValue(col, &value))
+
+	tv := &timeValue{}
+	assert.Nil(t, tv.Scan([]byte("2020-01-02")))
+	assert.Equal(t, "2020-01-02", columnValue(col, tv))
+	now := time.Now()
+	assert.Nil(t, tv.Scan(now))
+	assert.Equal(t, now, columnValue(col, tv))
+	assert.Nil(t, tv.Scan(nil))
+	assert.Nil(t, columnValue(col, tv))
+}
+
 func TestQuery_logSQL(t *testing.T) {
 	db := getDB()
 	q := db.NewQuery("SELECT * FROM users WHERE type={:type} AND id={:id}").Bind(Params{"type": "a", "id": 1})
 	expected := "SELECT * FROM users WHERE type='a' AND id=1"
 	assert.Equal(t, q.logSQL(), expected, "logSQL()")
+
+	q = db.NewQuery("SELECT '{:type}' FROM {{users}} WHERE type={:type} AND id={:id}").Bind(Params{"type": "a"})
+	expected = "SELECT '{:type}' FROM {{users}} WHERE type='a' AND id={:id}"
+	assert.Equal(t, q.logSQL(), expected, "logSQL()")
+
+	// encrypted values are not logged
+	c, _ := getTestCipher()
+	q = db.NewQuery("UPDATE users SET ssn={:ssn} WHERE id={:id}").Bind(Params{"ssn": encryptedValue{"123-45-6789", c, nil}, "id": 1})
+	expected = "UPDATE users SET ssn={:ssn} WHERE id=1"
+	assert.Equal(t, q.logSQL(), expected, "logSQL()")
 }
 
 func TestReplacePlaceholders(t *testing.T) {
@@ -319,13 +584,20 @@ func TestReplacePlaceholders(t *testing.T) {
 		{"t4", []string{"id", "name"}, Params{"id": 1, "name": "xyz", "age": 30}, `[1,"xyz"]`, false},
 	}
 	for _, test := range tests {
-		params, err := replacePlaceholders(test.Placeholders, test.Params)
+		params, err := replacePlaceholders(test.Placeholders, test.Params, nil)
 		result, _ := json.Marshal(params)
 		assert.Equal(t, string(result), test.ExpectedParams, "params@"+test.ID)
 		assert.Equal(t, err != nil, test.HasError, "error@"+test.ID)
 	}
 }
 
+func Test_replacePlaceholdersWithConverters(t *testing.T) {
+	params, err := replacePlaceholders([]string{"p0", "p1"}, Params{"p0": money{150}, "p1": 2}, testConverters)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []interface{}{"1.50", 2}, params)
+	}
+}
+
 func TestIssue6(t *testing.T) {
 	db := getPreparedDB()
 	q := db.Select("*").From("customer").Where(HashExp{"id": 1})
@@ -0,0 +1,335 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dbx
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// relationInfo describes a struct field declared as a relation using one of the relation options in the db tag.
+type relationInfo struct {
+	*fieldInfo
+	kind string       // belongsTo, hasOne, hasMany or manyToMany
+	elem reflect.Type // the related struct type
+}
+
+// relationKinds lists the db tag options declaring relations.
+var relationKinds = []string{"belongsTo", "hasOne", "hasMany", "manyToMany"}
+
+// relationKind returns the kind of the relation declared by the given db tag options, or an empty string
+// if the options do not declare a relation.
+func relationKind(options map[string]string) string {
+	for _, kind := range relationKinds {
+		if _, ok := options[kind]; ok {
+			return kind
+		}
+	}
+	return ""
+}
+
+// With specifies the relations to be loaded after the query populates structs by One, All or Model.
+//
+// A relation is named after the struct field declaring it with one of the following options in the db tag:
+//
+//   - "belongsTo": the field is a struct (or a pointer to a struct) whose primary key is referenced by the column
+//     specified by the "fk" option, which defaults to the mapped name of the field name suffixed with "ID".
+//   - "hasOne": the field is a struct (or a pointer to a struct) whose column specified by the "fk" option
+//     references the primary key of the model. The "fk" option defaults to the model table name (without the schema
+//     prefix and quotes) suffixed with "_id".
+//   - "hasMany": the same as "hasOne" except that the field is a slice of structs or struct pointers.
+//   - "manyToMany": the field is a slice of structs or struct pointers associated with the model through
+//     the join table specified by the "through" option. The "fk" and "ref" options specify the columns of the join
+//     table referencing the model and the related struct, and default to their table names suffixed with "_id"
+//     in the same way.
+//
+// The "ref" option of "belongsTo", "hasOne" and "hasMany" relations specifies the referenced column instead of
+// the primary key. For example,
+//
+//	type Order struct {
+//		ID         int
+//		CustomerID int
+//		Customer   *Customer `db:",belongsTo"`
+//		Items      []Item    `db:",manyToMany,through=order_item"`
+//	}
+//
+// Each relation is loaded for all populated structs by a single query using an IN condition on the relation keys,
+// plus a query on the join table for "manyToMany". Nested relations are specified using dots, such as "Orders.Items".
+func (s *SelectQuery) With(relations ...string) *SelectQuery {
+	s.with = append(s.with, relations...)
+	return s
+}
+
+// loadRelations loads the relations specified by With into a struct or into the elements of a slice of structs
+// or struct pointers starting from the given index. The struct and the slice must be given as pointers.
+func (s *SelectQuery) loadRelations(a interface{}, from int) error {
+	if len(s.with) == 0 {
+		return nil
+	}
+	v := reflect.Indirect(reflect.ValueOf(a))
+	var models []reflect.Value
+	if v.Kind() == reflect.Slice {
+		for i := from; i < v.Len(); i++ {
+			models = append(models, reflect.Indirect(v.Index(i)))
+		}
+		v = reflect.New(v.Type().Elem()).Elem()
+		if v.Kind() == reflect.Ptr {
+			v = reflect.New(v.Type().Elem()).Elem()
+		}
+	} else {
+		v = indirect(v)
+		models = append(models, v)
+	}
+	if v.Kind() != reflect.Struct {
+		return VarTypeError("relations can only be loaded into structs")
+	}
+	if len(models) == 0 {
+		return nil
+	}
+
+	t := v.Type()
+	si := getStructInfo(t, s.FieldMapper, s.converters())
+	var names []string
+	nested := map[string][]string{}
+	for _, relation := range s.with {
+		parts := strings.SplitN(relation, ".", 2)
+		if _, ok := nested[parts[0]]; !ok {
+			names = append(names, parts[0])
+			nested[parts[0]] = []string{}
+		}
+		if len(parts) == 2 {
+			nested[parts[0]] = append(nested[parts[0]], parts[1])
+		}
+	}
+	for _, name := range names {
+		ri, ok := si.relations[name]
+		if !ok {
+			return fmt.Errorf("unknown relation %q of %v", name, t)
+		}
+		if err := s.loadRelation(si, ri, models, nested[name]); err != nil {
+			return fmt.Errorf("failed to load relation %q of %v: %v", name, t, err)
+		}
+	}
+	return nil
+}
+
+// loadRelation loads a relation into the given structs using a single query on the related table,
+// and loads the nested relations of the related structs.
+func (s *SelectQuery) loadRelation(si *structInfo, ri *relationInfo, models []reflect.Value, nested []string) error {
+	isSlice := ri.kind == "hasMany" || ri.kind == "manyToMany"
+	if kind := ri.typ.Kind(); isSlice != (kind == reflect.Slice) || ri.elem.Kind() != reflect.Struct {
+		return VarTypeError("must be a struct, a pointer to a struct or a slice of them matching the relation kind")
+	}
+	table := s.TableMapper(reflect.New(models[0].Type()).Interface())
+	relatedTable := s.TableMapper(reflect.New(ri.elem).Interface())
+	rsi := getStructInfo(ri.elem, s.FieldMapper, s.converters())
+
+	var col, relatedCol string
+	var err error
+	switch ri.kind {
+	case "belongsTo":
+		col = ri.options["fk"]
+		if col == "" {
+			col = s.FieldMapper(ri.name + "ID")
+		}
+		if relatedCol = ri.options["ref"]; relatedCol == "" {
+			relatedCol, err = rsi.pkColumn()
+		}
+	case "hasOne", "hasMany":
+		if col = ri.options["ref"]; col == "" {
+			col, err = si.pkColumn()
+		}
+		relatedCol = ri.options["fk"]
+		if relatedCol == "" {
+			relatedCol = keyColumn(table)
+		}
+	default:
+		if col, err = si.pkColumn(); err == nil {
+			relatedCol, err = rsi.pkColumn()
+		}
+	}
+	if err != nil {
+		return err
+	}
+	fi, ok := si.dbNameMap[col]
+	if !ok {
+		return fmt.Errorf("column %q is not found in %v", col, models[0].Type())
+	}
+	rfi, ok := rsi.dbNameMap[relatedCol]
+	if !ok {
+		return fmt.Errorf("column %q is not found in %v", relatedCol, ri.elem)
+	}
+
+	// collect the keys of the models, which are mapped to the keys of the related structs for many-to-many relations
+	var keys []interface{}
+	seen := map[string]bool{}
+	for _, model := range models {
+		if key, id, ok := relationKey(fi.getValue(model)); ok && !seen[id] {
+			seen[id] = true
+			keys = append(keys, key)
+		}
+	}
+	var links map[string][]string
+	if ri.kind == "manyToMany" && len(keys) > 0 {
+		if links, keys, err = s.loadLinks(ri, table, relatedTable, fi.typ, rfi.typ, keys); err != nil {
+			return err
+		}
+	}
+
+	related := reflect.New(reflect.SliceOf(ri.elem))
+	if len(keys) > 0 {
+		q := s.builder.Select().WithContext(s.ctx).Where(In(relatedCol, keys...)).With(nested...)
+		q.FieldMapper, q.TableMapper, q.StrictMode = s.FieldMapper, s.TableMapper, s.StrictMode
+		if err := q.All(related.Interface()); err != nil {
+			return err
+		}
+	}
+	related = related.Elem()
+	groups := map[string][]reflect.Value{}
+	for i := 0; i < related.Len(); i++ {
+		if _, id, ok := relationKey(rfi.getValue(related.Index(i))); ok {
+			groups[id] = append(groups[id], related.Index(i))
+		}
+	}
+
+	for _, model := range models {
+		var matched []reflect.Value
+		if _, id, ok := relationKey(fi.getValue(model)); ok {
+			if links == nil {
+				matched = groups[id]
+			} else {
+				for _, relatedID := range links[id] {
+					matched = append(matched, groups[relatedID]...)
+				}
+			}
+		}
+		setRelation(ri.getField(model), matched)
+	}
+	return nil
+}
+
+// loadLinks queries the join table of a many-to-many relation. It returns the keys of the related structs indexed by
+// the keys of the models, as well as the distinct keys of the related structs. The columns of the join table are
+// scanned into the types of the key fields of the model and the related struct, so that they match the keys
+// in the same way as the key fields do.
+func (s *SelectQuery) loadLinks(ri *relationInfo, table, relatedTable string, typ, relatedTyp reflect.Type, keys []interface{}) (map[string][]string, []interface{}, error) {
+	through := ri.options["through"]
+	if through == "" {
+		return nil, nil, errors.New(`the "through" option is required by many-to-many relations`)
+	}
+	col := ri.options["fk"]
+	if col == "" {
+		col = keyColumn(table)
+	}
+	relatedCol := ri.options["ref"]
+	if relatedCol == "" {
+		relatedCol = keyColumn(relatedTable)
+	}
+
+	rows, err := s.builder.Select(col, relatedCol).From(through).Where(In(col, keys...)).WithContext(s.ctx).Rows()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	links := map[string][]string{}
+	var relatedKeys []interface{}
+	seen := map[string]bool{}
+	for rows.Next() {
+		// scanning into pointers to the key types allows null values
+		ref, relatedRef := reflect.New(reflect.PtrTo(typ)), reflect.New(reflect.PtrTo(relatedTyp))
+		if err := rows.Scan(ref.Interface(), relatedRef.Interface()); err != nil {
+			return nil, nil, err
+		}
+		if ref.Elem().IsNil() || relatedRef.Elem().IsNil() {
+			continue
+		}
+		_, id, ok := relationKey(ref.Elem().Elem().Interface())
+		relatedKey, relatedID, relatedOK := relationKey(relatedRef.Elem().Elem().Interface())
+		if !ok || !relatedOK {
+			continue
+		}
+		links[id] = append(links[id], relatedID)
+		if !seen[relatedID] {
+			seen[relatedID] = true
+			relatedKeys = append(relatedKeys, relatedKey)
+		}
+	}
+	return links, relatedKeys, rows.Close()
+}
+
+// relationKey returns the value of a relation key to be used in a query, and its string representation used to
+// match the related structs. The key is normalized into a driver.Value first, so that the keys of different Go types
+// holding the same database value match. False is returned if the key is null.
+func relationKey(value interface{}) (interface{}, string, bool) {
+	if v, err := driver.DefaultParameterConverter.ConvertValue(value); err == nil {
+		value = v
+	} else if _, ok := value.(driver.Valuer); ok {
+		return nil, "", false
+	}
+	// the types unknown to the driver package, such as those having converters, are used as is
+	switch v := value.(type) {
+	case nil:
+		return nil, "", false
+	case []byte:
+		return value, string(v), true
+	case time.Time:
+		return value, v.UTC().Format(time.RFC3339Nano), true
+	}
+	return value, fmt.Sprint(value), true
+}
+
+// keyColumn returns the default name of the column referencing the given table, which is the table name
+// without the schema prefix and quotes, suffixed with "_id".
+func keyColumn(table string) string {
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		table = table[i+1:]
+	}
+	return strings.Trim(table, "`\"[]{}") + "_id"
+}
+
+// setRelation populates a relation field with the matched related structs.
+// The field is set to an empty slice or to the zero value if nothing is matched.
+func setRelation(field reflect.Value, matched []reflect.Value) {
+	switch field.Kind() {
+	case reflect.Slice:
+		slice := reflect.MakeSlice(field.Type(), 0, len(matched))
+		for _, v := range matched {
+			if field.Type().Elem().Kind() == reflect.Ptr {
+				v = v.Addr()
+			}
+			slice = reflect.Append(slice, v)
+		}
+		field.Set(slice)
+	case reflect.Ptr:
+		if len(matched) > 0 {
+			field.Set(matched[0].Addr())
+		} else {
+			field.Set(reflect.Zero(field.Type()))
+		}
+	default:
+		if len(matched) > 0 {
+			field.Set(matched[0])
+		} else {
+			field.Set(reflect.Zero(field.Type()))
+		}
+	}
+}
+
+// pkColumn returns the primary key column name. An error is returned if the struct does not have
+// a single-column primary key.
+func (si *structInfo) pkColumn() (string, error) {
+	if len(si.pkNames) == 0 {
+		return "", MissingPKError
+	}
+	if len(si.pkNames) > 1 {
+		return "", errors.New("relations on composite primary keys are not supported")
+	}
+	return si.nameMap[si.pkNames[0]].dbName, nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dbx
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type relCustomer struct {
+	ID     int
+	Name   string
+	Orders []relOrder `db:",hasMany"`
+}
+
+func (relCustomer) TableName() string {
+	return "customer"
+}
+
+type relOrder struct {
+	ID         int
+	CustomerID int
+	Customer   *relCustomer `db:",belongsTo"`
+	Items      []*relItem   `db:",manyToMany,through=order_item"`
+}
+
+func (relOrder) TableName() string {
+	return "order"
+}
+
+type relItem struct {
+	ID   int
+	Name string
+}
+
+func (relItem) TableName() string {
+	return "item"
+}
+
+func TestSelectQuery_With(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	var customers []relCustomer
+	err := db.Select().OrderBy("id").With("Orders.Items").All(&customers)
+	if assert.Nil(t, err) && assert.Len(t, customers, 3) {
+		assert.Len(t, customers[0].Orders, 1)
+		assert.Len(t, customers[1].Orders, 2)
+		assert.NotNil(t, customers[2].Orders)
+		assert.Len(t, customers[2].Orders, 0)
+		if assert.Len(t, customers[0].Orders[0].Items, 2) {
+			names := []string{customers[0].Orders[0].Items[0].Name, customers[0].Orders[0].Items[1].Name}
+			assert.ElementsMatch(t, []string{"The Go Programming Language", "Go in Action"}, names)
+		}
+		assert.Nil(t, customers[0].Orders[0].Customer)
+	}
+
+	var order relOrder
+	err = db.Select().With("Customer", "Items").Model(2, &order)
+	if assert.Nil(t, err) {
+		if assert.NotNil(t, order.Customer) {
+			assert.Equal(t, "user2", order.Customer.Name)
+		}
+		assert.Len(t, order.Items, 3)
+	}
+
+	var orders []relOrder
+	err = db.Select().With("Customer").All(&orders)
+	if assert.Nil(t, err) && assert.Len(t, orders, 3) {
+		assert.Same(t, orders[1].Customer, orders[2].Customer)
+	}
+
+	err = db.Select().With("Unknown").All(&orders)
+	assert.NotNil(t, err)
+}
+
+func Test_structInfo_relations(t *testing.T) {
+	si := getStructInfo(reflect.TypeOf(relOrder{}), DefaultFieldMapFunc, nil)
+	assert.Len(t, si.nameMap, 2)
+	if assert.Len(t, si.relations, 2) {
+		assert.Equal(t, "belongsTo", si.relations["Customer"].kind)
+		assert.Equal(t, reflect.TypeOf(relCustomer{}), si.relations["Customer"].elem)
+		assert.Equal(t, "manyToMany", si.relations["Items"].kind)
+		assert.Equal(t, reflect.TypeOf(relItem{}), si.relations["Items"].elem)
+		assert.Equal(t, "order_item", si.relations["Items"].options["through"])
+	}
+}
+
+func TestSelectQuery_loadRelations(t *testing.T) {
+	db := getDB()
+
+	var order relOrder
+	assert.Nil(t, db.Select().loadRelations(&order, 0))
+	assert.NotNil(t, db.Select().With("Unknown").loadRelations(&order, 0))
+
+	var orders []relOrder
+	assert.Nil(t, db.Select().With("Customer").loadRelations(&orders, 0))
+
+	row := NullStringMap{}
+	assert.NotNil(t, db.Select().With("Customer").loadRelations(&row, 0))
+
+	var invalid struct {
+		ID     int
+		Orders relOrder `db:",hasMany"`
+	}
+	assert.NotNil(t, db.Select().With("Orders").loadRelations(&invalid, 0))
+}
+
+func Test_relationKey(t *testing.T) {
+	key, id, ok := relationKey(1)
+	assert.Equal(t, int64(1), key)
+	assert.Equal(t, "1", id)
+	assert.True(t, ok)
+
+	key, id, ok = relationKey(sql.NullInt64{Int64: 2, Valid: true})
+	assert.Equal(t, int64(2), key)
+	assert.Equal(t, "2", id)
+	assert.True(t, ok)
+
+	_, _, ok = relationKey(sql.NullInt64{})
+	assert.False(t, ok)
+	_, _, ok = relationKey(nil)
+	assert.False(t, ok)
+	_, _, ok = relationKey((*int)(nil))
+	assert.False(t, ok)
+
+	// keys of different types holding the same value match
+	n := uint16(3)
+	_, id1, _ := relationKey(&n)
+	_, id2, _ := relationKey(sql.NullInt32{Int32: 3, Valid: true})
+	assert.Equal(t, id1, id2)
+	_, id1, _ = relationKey([]byte("abc"))
+	_, id2, _ = relationKey(sql.NullString{String: "abc", Valid: true})
+	assert.Equal(t, id1, id2)
+	tm := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	_, id1, _ = relationKey(tm)
+	_, id2, _ = relationKey(tm.In(time.FixedZone("UTC+8", 8*3600)))
+	assert.Equal(t, id1, id2)
+}
+
+func Test_keyColumn(t *testing.T) {
+	assert.Equal(t, "user_id", keyColumn("user"))
+	assert.Equal(t, "user_id", keyColumn("schema.user"))
+	assert.Equal(t, "user_id", keyColumn(`"schema"."user"`))
+	assert.Equal(t, "user_id", keyColumn("`user`"))
+	assert.Equal(t, "user_id", keyColumn("[dbo].[user]"))
+	assert.Equal(t, "user_id", keyColumn("{{user}}"))
+}
+
+func Test_setRelation(t *testing.T) {
+	items := []relItem{{1, "a"}, {2, "b"}}
+	matched := []reflect.Value{reflect.ValueOf(items).Index(0), reflect.ValueOf(items).Index(1)}
+
+	var model struct {
+		Items    []relItem
+		ItemPtrs []*relItem
+		Item     relItem
+		ItemPtr  *relItem
+	}
+	v := reflect.ValueOf(&model).Elem()
+	setRelation(v.Field(0), matched)
+	setRelation(v.Field(1), matched)
+	setRelation(v.Field(2), matched)
+	setRelation(v.Field(3), matched)
+	assert.Equal(t, items, model.Items)
+	if assert.Len(t, model.ItemPtrs, 2) {
+		assert.Same(t, &items[1], model.ItemPtrs[1])
+	}
+	assert.Equal(t, items[0], model.Item)
+	assert.Same(t, &items[0], model.ItemPtr)
+
+	setRelation(v.Field(0), nil)
+	setRelation(v.Field(3), nil)
+	assert.Equal(t, []relItem{}, model.Items)
+	assert.Nil(t, model.ItemPtr)
+}
@@ -5,8 +5,12 @@
 package dbx
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 )
 
 // VarTypeError indicates a variable type error when trying to populating a variable with DB result.
@@ -17,6 +21,37 @@ func (s VarTypeError) Error() string {
 	return "Invalid variable type: " + string(s)
 }
 
+// The checks that can be specified by StrictMode.
+const (
+	// StrictColumns reports the result columns which are not mapped to any struct field.
+	StrictColumns StrictMode = 1 << iota
+	// StrictFields reports the struct fields which are not populated by any result column.
+	StrictFields
+)
+
+// ScanError is returned when the result columns of a query do not match the fields of the struct being populated
+// as required by StrictMode.
+type ScanError struct {
+	// Type is the type of the struct being populated.
+	Type reflect.Type
+	// Columns lists the result columns which are not mapped to any field of the struct.
+	Columns []string
+	// Fields lists the names of the struct fields which are not populated by any result column.
+	Fields []string
+}
+
+// Error returns the error message.
+func (e *ScanError) Error() string {
+	var msgs []string
+	if len(e.Columns) > 0 {
+		msgs = append(msgs, "columns without fields: "+strings.Join(e.Columns, ", "))
+	}
+	if len(e.Fields) > 0 {
+		msgs = append(msgs, "fields without columns: "+strings.Join(e.Fields, ", "))
+	}
+	return fmt.Sprintf("cannot populate %v: %v", e.Type, strings.Join(msgs, "; "))
+}
+
 // NullStringMap is a map of sql.NullString that can be used to hold DB query result.
 // The map keys correspond to the DB column names, while the map values are their corresponding column values.
 type NullStringMap map[string]sql.NullString
@@ -26,6 +61,13 @@ type NullStringMap map[string]sql.NullString
 type Rows struct {
 	*sql.Rows
 	fieldMapFunc FieldMapFunc
+	strictMode   StrictMode
+	ctx          context.Context
+	db           *DB
+	executor     Executor
+	checked      map[reflect.Type]error
+	pending      []interface{}
+	hookErr      error
 }
 
 // ScanMap populates the current row of data into a NullStringMap.
@@ -50,6 +92,50 @@ func (r *Rows) ScanMap(a NullStringMap) error {
 	return nil
 }
 
+// ScanMapNative populates the current row of data into a map[string]interface{}.
+// The map must not be nil. It will be populated using column names as keys and their values as
+// the corresponding element values.
+//
+// Unlike ScanMap, the values keep their native types chosen according to the column types reported by
+// the driver: int64, float64, bool, time.Time, string, []byte, or nil for null values. Byte slices are copied,
+// so they remain valid after the next row is read.
+func (r *Rows) ScanMapNative(a map[string]interface{}) error {
+	if a == nil {
+		return VarTypeError("the map is nil")
+	}
+	cols, err := r.ColumnTypes()
+	if err != nil {
+		return err
+	}
+	refs := make([]interface{}, len(cols))
+	for i, col := range cols {
+		refs[i] = newColumnValue(col)
+	}
+	if err := r.Scan(refs...); err != nil {
+		return err
+	}
+
+	for i, col := range cols {
+		a[col.Name()] = columnValue(col, refs[i])
+	}
+	return nil
+}
+
+// scanMap populates the current row of data into a NullStringMap or a map[string]interface{}
+// (or a map type of the same underlying type) using ScanMap or ScanMapNative, respectively.
+func (r *Rows) scanMap(v reflect.Value) error {
+	if m, ok := v.Interface().(NullStringMap); ok {
+		if m == nil {
+			return VarTypeError("NullStringMap is nil")
+		}
+		return r.ScanMap(m)
+	}
+	if !isValueMap(v.Type()) {
+		return VarTypeError("must be a NullStringMap or a map[string]interface{}")
+	}
+	return r.ScanMapNative(v.Convert(valueMapType).Interface().(map[string]interface{}))
+}
+
 // ScanStruct populates the current row of data into a struct.
 // The struct must be given as a pointer.
 //
@@ -62,34 +148,103 @@ func (r *Rows) ScanMap(a NullStringMap) error {
 // For example, "LastName" is mapped to "last_name", "MyID" is mapped to "my_id", and so on.
 // To change the default behavior, set DB.FieldMapper with your custom mapping function.
 // You may also set Query.FieldMapper to change the behavior for particular queries.
+//
+// If the struct implements AfterFindHook, the hook is not called right away because the result set is still open,
+// and the hook would not be able to execute other queries on the same connection. Instead, the hook is called
+// after the rows are closed, either by Close or by Next returning false, and its error is returned by Close and Err.
+// Therefore, each row should be populated into a different struct, as a struct reused for several rows only holds
+// the last of them when its hooks are called.
 func (r *Rows) ScanStruct(a interface{}) error {
+	rv, err := r.scanStruct(a)
+	if err != nil {
+		return err
+	}
+	a = rv.Addr().Interface()
+	if _, ok := a.(AfterFindHook); ok && r.db != nil {
+		r.pending = append(r.pending, a)
+		return nil
+	}
+	return r.afterFind(a)
+}
+
+// scanStruct populates the current row of data into a struct without calling its AfterFind hook,
+// and returns the struct.
+func (r *Rows) scanStruct(a interface{}) (reflect.Value, error) {
 	rv := reflect.ValueOf(a)
 	if rv.Kind() != reflect.Ptr || rv.IsNil() {
-		return VarTypeError("must be a pointer")
+		return rv, VarTypeError("must be a pointer")
 	}
 	rv = indirect(rv)
 	if rv.Kind() != reflect.Struct {
-		return VarTypeError("must be a pointer to a struct")
+		return rv, VarTypeError("must be a pointer to a struct")
 	}
 
-	si := getStructInfo(rv.Type(), r.fieldMapFunc)
+	si := getStructInfo(rv.Type(), r.fieldMapFunc, r.converters())
 
 	cols, _ := r.Columns()
+	if err := r.checkStruct(rv.Type(), si, cols); err != nil {
+		return rv, err
+	}
 	refs := make([]interface{}, len(cols))
 
 	for i, col := range cols {
 		if fi, ok := si.dbNameMap[col]; ok {
-			refs[i] = fi.getField(rv).Addr().Interface()
+			refs[i] = fi.scanTarget(rv, r.db)
 		} else {
 			refs[i] = &sql.NullString{}
 		}
 	}
 
-	return r.Scan(refs...)
+	return rv, r.Scan(refs...)
 }
 
-// all populates all rows of query result into a slice of struct or NullStringMap.
-// Note that the slice must be given as a pointer.
+// Next prepares the next row for reading. It returns false if there is no more row or an error occurs,
+// in which case the rows are closed and the pending AfterFind hooks of the structs populated by ScanStruct
+// are called.
+func (r *Rows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.callHooks()
+	return false
+}
+
+// Close closes the rows and calls the pending AfterFind hooks of the structs populated by ScanStruct.
+// If the rows are closed successfully, the first error returned by the hooks is returned.
+func (r *Rows) Close() error {
+	err := r.Rows.Close()
+	if e := r.callHooks(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// Err returns the error encountered during the iteration, or the first error returned by the AfterFind hooks
+// called after the rows were closed.
+func (r *Rows) Err() error {
+	if err := r.Rows.Err(); err != nil {
+		return err
+	}
+	return r.hookErr
+}
+
+// callHooks calls the pending AfterFind hooks and returns the first error returned by them.
+// The remaining hooks are not called once a hook returns an error.
+func (r *Rows) callHooks() error {
+	pending := r.pending
+	r.pending = nil
+	for _, a := range pending {
+		if r.hookErr != nil {
+			break
+		}
+		r.hookErr = r.afterFind(a)
+	}
+	return r.hookErr
+}
+
+// all populates all rows of query result into a slice of structs, struct pointers, NullStringMap or
+// map[string]interface{}. A slice of other types, such as []int or []sql.NullString, is populated with the first
+// column of the query result as done by column. Note that the slice must be given as a pointer.
 func (r *Rows) all(slice interface{}) error {
 	defer r.Close()
 
@@ -100,7 +255,7 @@ func (r *Rows) all(slice interface{}) error {
 	v = indirect(v)
 
 	if v.Kind() != reflect.Slice {
-		return VarTypeError("must be a slice of struct or NullStringMap")
+		return VarTypeError("must be a slice")
 	}
 
 	if v.IsNil() {
@@ -111,32 +266,40 @@ func (r *Rows) all(slice interface{}) error {
 	et := v.Type().Elem()
 
 	if et.Kind() == reflect.Map {
+		if et != reflect.TypeOf(NullStringMap{}) && !isValueMap(et) {
+			return VarTypeError("must be a slice of struct, NullStringMap or map[string]interface{}")
+		}
 		for r.Next() {
-			ev, ok := reflect.MakeMap(et).Interface().(NullStringMap)
-			if !ok {
-				return VarTypeError("must be a slice of struct or NullStringMap")
-			}
-			if err := r.ScanMap(ev); err != nil {
+			ev := reflect.MakeMap(et)
+			if err := r.scanMap(ev); err != nil {
 				return err
 			}
-			v.Set(reflect.Append(v, reflect.ValueOf(ev)))
+			v.Set(reflect.Append(v, ev))
 		}
 		return r.Close()
 	}
 
-	if et.Kind() != reflect.Struct {
-		return VarTypeError("must be a slice of struct or NullStringMap")
+	if !r.isStruct(et) {
+		return r.column(slice)
+	}
+	isPtr := et.Kind() == reflect.Ptr
+	if isPtr {
+		et = et.Elem()
 	}
 
-	si := getStructInfo(et, r.fieldMapFunc)
+	si := getStructInfo(et, r.fieldMapFunc, r.converters())
 
+	n := v.Len()
 	cols, _ := r.Columns()
+	if err := r.checkStruct(et, si, cols); err != nil {
+		return err
+	}
 	for r.Next() {
 		ev := reflect.New(et).Elem()
 		refs := make([]interface{}, len(cols))
 		for i, col := range cols {
 			if fi, ok := si.dbNameMap[col]; ok {
-				refs[i] = fi.getField(ev).Addr().Interface()
+				refs[i] = fi.scanTarget(ev, r.db)
 			} else {
 				refs[i] = &sql.NullString{}
 			}
@@ -144,10 +307,107 @@ func (r *Rows) all(slice interface{}) error {
 		if err := r.Scan(refs...); err != nil {
 			return err
 		}
+		if isPtr {
+			ev = ev.Addr()
+		}
 		v.Set(reflect.Append(v, ev))
 	}
 
-	return r.Close()
+	if err := r.Close(); err != nil {
+		return err
+	}
+	for i := n; i < v.Len(); i++ {
+		ev := v.Index(i)
+		if !isPtr {
+			ev = ev.Addr()
+		}
+		if err := r.afterFind(ev.Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isStruct checks if the given type is a struct or a pointer to a struct whose fields are populated with
+// the columns of the query result. Scanners, time.Time and the types having converters are populated as a whole.
+func (r *Rows) isStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return isNestedStruct(t, r.converters())
+}
+
+// checkStruct checks if the result columns match the fields of the given struct type by calling checkColumns.
+// As the columns of the rows do not change, the check is done only once for each struct type.
+func (r *Rows) checkStruct(t reflect.Type, si *structInfo, cols []string) error {
+	if r.strictMode == 0 {
+		return nil
+	}
+	err, ok := r.checked[t]
+	if !ok {
+		err = r.checkColumns(t, si, cols)
+		if r.checked == nil {
+			r.checked = map[reflect.Type]error{}
+		}
+		r.checked[t] = err
+	}
+	return err
+}
+
+// checkColumns checks if the result columns match the fields of the given struct type as required by the strict mode.
+// A *ScanError is returned if they do not match.
+func (r *Rows) checkColumns(t reflect.Type, si *structInfo, cols []string) error {
+	if r.strictMode == 0 {
+		return nil
+	}
+	e := &ScanError{Type: t}
+	if r.strictMode&StrictColumns != 0 {
+		for _, col := range cols {
+			if _, ok := si.dbNameMap[col]; !ok {
+				e.Columns = append(e.Columns, col)
+			}
+		}
+	}
+	if r.strictMode&StrictFields != 0 {
+		found := make(map[string]bool, len(cols))
+		for _, col := range cols {
+			found[col] = true
+		}
+		for name, fi := range si.nameMap {
+			if !found[fi.dbName] {
+				e.Fields = append(e.Fields, name)
+			}
+		}
+		sort.Strings(e.Fields)
+	}
+	if len(e.Columns) == 0 && len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// converters returns the converters registered in the DB associated with the rows.
+func (r *Rows) converters() map[reflect.Type]Converter {
+	if r.db == nil {
+		return nil
+	}
+	return r.db.Converters
+}
+
+// afterFind calls the AfterFind hook of the given struct pointer if it implements AfterFindHook, and then takes
+// the snapshot of the struct so that the changes made by the hook are not reported as dirty.
+// The hook is given a builder which uses the same executor (a DB or a transaction) as the rows.
+func (r *Rows) afterFind(a interface{}) error {
+	if h, ok := a.(AfterFindHook); ok && r.db != nil {
+		if err := h.AfterFind(hookContext(r.ctx), r.db.newBuilder(r.executor)); err != nil {
+			return err
+		}
+	}
+	if _, ok := a.(tracker); ok {
+		v := reflect.ValueOf(a).Elem()
+		takeSnapshot(getStructInfo(v.Type(), r.fieldMapFunc, r.converters()), v)
+	}
+	return nil
 }
 
 // column populates the given slice with the first column of the query result.
@@ -167,18 +427,9 @@ func (r *Rows) column(slice interface{}) error {
 
 	et := v.Type().Elem()
 
-	cols, _ := r.Columns()
 	for r.Next() {
 		ev := reflect.New(et)
-		refs := make([]interface{}, len(cols))
-		for i := range cols {
-			if i == 0 {
-				refs[i] = ev.Interface()
-			} else {
-				refs[i] = &sql.NullString{}
-			}
-		}
-		if err := r.Scan(refs...); err != nil {
+		if err := r.scanColumn(ev.Interface()); err != nil {
 			return err
 		}
 		v.Set(reflect.Append(v, ev.Elem()))
@@ -187,8 +438,9 @@ func (r *Rows) column(slice interface{}) error {
 	return r.Close()
 }
 
-// one populates a single row of query result into a struct or a NullStringMap.
-// Note that if a struct is given, it should be a pointer.
+// one populates a single row of query result into a struct, a NullStringMap or a map[string]interface{}.
+// Note that if a struct is given, it should be a pointer. If a pointer to another type is given, such as *int,
+// it is populated with the first column of the query result.
 func (r *Rows) one(a interface{}) error {
 	defer r.Close()
 
@@ -213,23 +465,43 @@ func (r *Rows) one(a interface{}) error {
 	}
 
 	if rt.Kind() == reflect.Map {
-		v, ok := a.(NullStringMap)
-		if !ok {
-			return VarTypeError("must be a NullStringMap")
-		}
-		if v == nil {
-			return VarTypeError("NullStringMap is nil")
-		}
-		err = r.ScanMap(v)
+		err = r.scanMap(reflect.ValueOf(a))
+	} else if rt.Kind() == reflect.Ptr && !r.isStruct(rt.Elem()) {
+		err = r.scanColumn(a)
 	} else {
-		err = r.ScanStruct(a)
+		var rv reflect.Value
+		if rv, err = r.scanStruct(a); err == nil {
+			// call the hook of the struct even if it is given as a pointer to a pointer
+			a = rv.Addr().Interface()
+		}
 	}
 
 	if err != nil {
 		return err
 	}
 
-	return r.Close()
+	if err := r.Close(); err != nil {
+		return err
+	}
+	return r.afterFind(a)
+}
+
+// scanColumn populates the first column of the current row into the variable given as a pointer.
+func (r *Rows) scanColumn(a interface{}) error {
+	v := reflect.ValueOf(a)
+	if v.IsNil() {
+		return VarTypeError("must be a non-nil pointer")
+	}
+	cols, _ := r.Columns()
+	refs := make([]interface{}, len(cols))
+	for i := range cols {
+		if i == 0 {
+			refs[i] = scanTarget(v.Elem(), r.converters())
+		} else {
+			refs[i] = &sql.NullString{}
+		}
+	}
+	return r.Scan(refs...)
 }
 
 // row populates a single row of query result into a list of variables.
@@ -254,3 +526,112 @@ func (r *Rows) row(a ...interface{}) error {
 
 	return r.Close()
 }
+
+var (
+	rawBytesType = reflect.TypeOf(sql.RawBytes{})
+	bytesType    = reflect.TypeOf([]byte{})
+	valueMapType = reflect.TypeOf(map[string]interface{}{})
+)
+
+// isValueMap checks if a type is map[string]interface{} or a map type having it as the underlying type, such as Params.
+func isValueMap(t reflect.Type) bool {
+	return t.Kind() == reflect.Map && t.ConvertibleTo(valueMapType)
+}
+
+// newColumnValue returns a pointer to a variable suitable for scanning the values of the given column.
+// The variable is chosen according to the scan type reported by the driver.
+func newColumnValue(col *sql.ColumnType) interface{} {
+	t := col.ScanType()
+	if t == nil {
+		return new(interface{})
+	}
+	switch t {
+	case reflect.TypeOf(sql.NullInt64{}), reflect.TypeOf(sql.NullInt32{}):
+		return &sql.NullInt64{}
+	case reflect.TypeOf(sql.NullFloat64{}):
+		return &sql.NullFloat64{}
+	case reflect.TypeOf(sql.NullBool{}):
+		return &sql.NullBool{}
+	case reflect.TypeOf(sql.NullString{}):
+		return &sql.NullString{}
+	case reflect.TypeOf(sql.NullTime{}), timeType:
+		return &timeValue{}
+	case rawBytesType, bytesType:
+		return new(interface{})
+	}
+	if t.Kind() == reflect.Struct && strings.HasSuffix(t.Name(), "NullTime") {
+		// driver-specific null time types, such as mysql.NullTime
+		return &timeValue{}
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &sql.NullInt64{}
+	case reflect.Float32, reflect.Float64:
+		return &sql.NullFloat64{}
+	case reflect.Bool:
+		return &sql.NullBool{}
+	case reflect.String:
+		return &sql.NullString{}
+	}
+	return new(interface{})
+}
+
+// textTypes lists the keywords of the database type names whose values should be returned as strings
+// rather than byte slices.
+var textTypes = []string{"CHAR", "TEXT", "CLOB", "JSON", "ENUM", "SET", "DECIMAL", "NUMERIC", "UUID", "XML"}
+
+// columnValue returns the value scanned into a variable created by newColumnValue, or nil if it is null.
+// Byte slices are copied, and they are converted into strings for text columns.
+func columnValue(col *sql.ColumnType, ref interface{}) interface{} {
+	switch v := ref.(type) {
+	case *sql.NullInt64:
+		if v.Valid {
+			return v.Int64
+		}
+	case *sql.NullFloat64:
+		if v.Valid {
+			return v.Float64
+		}
+	case *sql.NullBool:
+		if v.Valid {
+			return v.Bool
+		}
+	case *sql.NullString:
+		if v.Valid {
+			return v.String
+		}
+	case *timeValue:
+		return v.value
+	case *interface{}:
+		b, ok := (*v).([]byte)
+		if !ok {
+			return *v
+		}
+		name := strings.ToUpper(col.DatabaseTypeName())
+		for _, typ := range textTypes {
+			if strings.Contains(name, typ) {
+				return string(b)
+			}
+		}
+		return append([]byte{}, b...)
+	}
+	return nil
+}
+
+// timeValue scans the value of a time column, which is a time.Time or a string if the driver
+// does not parse time values (e.g. the MySQL driver without parseTime=true).
+type timeValue struct {
+	value interface{}
+}
+
+// Scan implements sql.Scanner.
+func (v *timeValue) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case []byte:
+		v.value = string(src)
+	default:
+		v.value = src
+	}
+	return nil
+}
@@ -6,8 +6,11 @@ package dbx
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 // SelectQuery represents a DB-agnostic SELECT query.
@@ -17,25 +20,41 @@ type SelectQuery struct {
 	FieldMapper FieldMapFunc
 	// TableMapper maps structs to DB table names.
 	TableMapper TableMapFunc
+	// StrictMode specifies the checks performed when populating query results into structs.
+	StrictMode StrictMode
 
 	builder Builder
+	db      *DB
 	ctx     context.Context
 
-	selects      []string
+	selects      []interface{} // column names or expressions
 	distinct     bool
+	distinctOn   []string
 	selectOption string
 	from         []string
+	indexHints   []IndexHint
 	where        Expression
 	join         []JoinInfo
 	orderBy      []string
 	groupBy      []string
+	grouping     *GroupingInfo
 	having       Expression
 	union        []UnionInfo
 	limit        int64
 	offset       int64
 	params       Params
+	deleted      int      // how soft-deleted rows are selected
+	with         []string // relations to be loaded
+	lastError    error
 }
 
+// the ways of selecting soft-deleted rows
+const (
+	excludeDeleted = iota
+	withDeleted
+	onlyDeleted
+)
+
 // JoinInfo contains the specification for a JOIN clause.
 type JoinInfo struct {
 	Join  string
@@ -43,6 +62,48 @@ type JoinInfo struct {
 	On    Expression
 }
 
+// IndexHint contains the specification for an index hint on a table in the FROM or JOIN clause.
+type IndexHint struct {
+	// Table is the name or the alias of the table as specified in the FROM or JOIN clause.
+	Table string
+	// Type is the type of the hint, which can be "USE", "FORCE" or "IGNORE".
+	Type string
+	// Indexes lists the names of the indexes.
+	Indexes []string
+}
+
+// GroupingInfo contains the specification for a ROLLUP, CUBE or GROUPING SETS construct in a GROUP BY clause.
+type GroupingInfo struct {
+	// Type is the type of the construct, which can be "ROLLUP", "CUBE" or "GROUPING SETS".
+	Type string
+	// Sets lists the sets of grouping columns. ROLLUP and CUBE have a single set.
+	Sets [][]string
+}
+
+// validate checks if the grouping construct has columns to group by. ROLLUP and CUBE require at least one column,
+// while GROUPING SETS requires at least one set, although a set may be empty.
+func (g *GroupingInfo) validate() error {
+	if len(g.Sets) == 0 {
+		return errors.New(g.Type + " requires at least one grouping set")
+	}
+	if g.Type != "GROUPING SETS" && len(g.Sets[0]) == 0 {
+		return errors.New(g.Type + " requires at least one column")
+	}
+	return nil
+}
+
+// PageInfo contains the pagination information of a page fetched by SelectQuery.Paginate.
+type PageInfo struct {
+	// Page is the 1-based number of the current page.
+	Page int64
+	// PerPage is the maximum number of items in a page. It is 0 if the items are not paginated.
+	PerPage int64
+	// TotalCount is the total number of items.
+	TotalCount int64
+	// PageCount is the total number of pages.
+	PageCount int64
+}
+
 // UnionInfo contains the specification for a UNION clause.
 type UnionInfo struct {
 	All   bool
@@ -53,7 +114,8 @@ type UnionInfo struct {
 func NewSelectQuery(builder Builder, db *DB) *SelectQuery {
 	return &SelectQuery{
 		builder:     builder,
-		selects:     []string{},
+		db:          db,
+		selects:     []interface{}{},
 		from:        []string{},
 		join:        []JoinInfo{},
 		orderBy:     []string{},
@@ -64,6 +126,7 @@ func NewSelectQuery(builder Builder, db *DB) *SelectQuery {
 		ctx:         db.ctx,
 		FieldMapper: db.FieldMapper,
 		TableMapper: db.TableMapper,
+		StrictMode:  db.StrictMode,
 	}
 }
 
@@ -81,14 +144,23 @@ func (q *SelectQuery) WithContext(ctx context.Context) *SelectQuery {
 // Select specifies the columns to be selected.
 // Column names will be automatically quoted.
 func (s *SelectQuery) Select(cols ...string) *SelectQuery {
-	s.selects = cols
-	return s
+	s.selects = make([]interface{}, 0, len(cols))
+	return s.AndSelect(cols...)
 }
 
 // AndSelect adds additional columns to be selected.
 // Column names will be automatically quoted.
 func (s *SelectQuery) AndSelect(cols ...string) *SelectQuery {
-	s.selects = append(s.selects, cols...)
+	for _, col := range cols {
+		s.selects = append(s.selects, col)
+	}
+	return s
+}
+
+// AndSelectExp adds an expression, such as a subquery created by AsExp(), to the columns to be selected.
+// The expression will be enclosed within parenthesis and named with the given alias if it is not empty.
+func (s *SelectQuery) AndSelectExp(e Expression, alias string) *SelectQuery {
+	s.selects = append(s.selects, &selectExp{e, alias})
 	return s
 }
 
@@ -99,6 +171,14 @@ func (s *SelectQuery) Distinct(v bool) *SelectQuery {
 	return s
 }
 
+// DistinctOn specifies the columns of a DISTINCT ON option which keeps only the first row of each set of rows
+// having the same values of the columns. Column names will be properly quoted.
+// DistinctOn is only supported by PostgreSQL and takes precedence over Distinct.
+func (s *SelectQuery) DistinctOn(cols ...string) *SelectQuery {
+	s.distinctOn = cols
+	return s
+}
+
 // SelectOption specifies additional option that should be append to "SELECT".
 func (s *SelectQuery) SelectOption(option string) *SelectQuery {
 	s.selectOption = option
@@ -112,6 +192,28 @@ func (s *SelectQuery) From(tables ...string) *SelectQuery {
 	return s
 }
 
+// UseIndex specifies a hint suggesting the indexes to be used for the named table in the FROM or JOIN clause.
+// The table should be given as its name or its alias if any. Index hints are supported by MySQL and SQL Server.
+// The query returns an error if the table is not found in the FROM or JOIN clauses.
+func (s *SelectQuery) UseIndex(table string, indexes ...string) *SelectQuery {
+	s.indexHints = append(s.indexHints, IndexHint{table, "USE", indexes})
+	return s
+}
+
+// ForceIndex specifies a hint forcing the indexes to be used for the named table in the FROM or JOIN clause.
+// The table should be given as its name or its alias if any. Index hints are supported by MySQL and SQL Server.
+func (s *SelectQuery) ForceIndex(table string, indexes ...string) *SelectQuery {
+	s.indexHints = append(s.indexHints, IndexHint{table, "FORCE", indexes})
+	return s
+}
+
+// IgnoreIndex specifies a hint preventing the indexes from being used for the named table in the FROM or JOIN clause.
+// The table should be given as its name or its alias if any. Ignoring indexes is only supported by MySQL.
+func (s *SelectQuery) IgnoreIndex(table string, indexes ...string) *SelectQuery {
+	s.indexHints = append(s.indexHints, IndexHint{table, "IGNORE", indexes})
+	return s
+}
+
 // Where specifies the WHERE condition.
 func (s *SelectQuery) Where(e Expression) *SelectQuery {
 	s.where = e
@@ -155,6 +257,45 @@ func (s *SelectQuery) RightJoin(table string, on Expression) *SelectQuery {
 	return s.Join("RIGHT JOIN", table, on)
 }
 
+// FullJoin specifies a FULL JOIN clause.
+// This is a shortcut method for Join. FULL JOIN is not supported by MySQL and SQLite.
+func (s *SelectQuery) FullJoin(table string, on Expression) *SelectQuery {
+	return s.Join("FULL JOIN", table, on)
+}
+
+// CrossJoin specifies a CROSS JOIN clause.
+// This is a shortcut method for Join.
+func (s *SelectQuery) CrossJoin(table string) *SelectQuery {
+	return s.Join("CROSS JOIN", table, nil)
+}
+
+// NaturalJoin specifies a NATURAL JOIN clause.
+// This is a shortcut method for Join. NATURAL JOIN is not supported by SQL Server.
+func (s *SelectQuery) NaturalJoin(table string) *SelectQuery {
+	return s.Join("NATURAL JOIN", table, nil)
+}
+
+// JoinUsing specifies a JOIN clause whose condition is given as a USING list of columns.
+// The "typ" parameter specifies the JOIN type (e.g. "INNER JOIN", "LEFT JOIN").
+// Column names will be properly quoted. JOIN USING is not supported by SQL Server.
+func (s *SelectQuery) JoinUsing(typ string, table string, cols ...string) *SelectQuery {
+	return s.Join(typ, table, &usingExp{cols})
+}
+
+// LateralJoin specifies a CROSS JOIN LATERAL clause, which is usually used to join a subquery
+// that refers to the preceding tables, e.g. LateralJoin("(SELECT * FROM orders WHERE user_id=users.id) o").
+// It is generated as CROSS APPLY by SQL Server and Oracle, and is not supported by SQLite.
+func (s *SelectQuery) LateralJoin(table string) *SelectQuery {
+	return s.Join("CROSS JOIN LATERAL", table, nil)
+}
+
+// LeftLateralJoin specifies a LEFT JOIN LATERAL clause. When "on" is nil, the join condition is always true.
+// It is generated as OUTER APPLY by SQL Server and Oracle, which requires "on" to be nil,
+// and is not supported by SQLite.
+func (s *SelectQuery) LeftLateralJoin(table string, on Expression) *SelectQuery {
+	return s.Join("LEFT JOIN LATERAL", table, on)
+}
+
 // OrderBy specifies the ORDER BY clause.
 // Column names will be properly quoted. A column name can contain "ASC" or "DESC" to indicate its ordering direction.
 func (s *SelectQuery) OrderBy(cols ...string) *SelectQuery {
@@ -183,6 +324,28 @@ func (s *SelectQuery) AndGroupBy(cols ...string) *SelectQuery {
 	return s
 }
 
+// Rollup specifies a ROLLUP construct following the GROUP BY columns.
+// Column names will be properly quoted. MySQL only supports ROLLUP without other GROUP BY columns.
+func (s *SelectQuery) Rollup(cols ...string) *SelectQuery {
+	s.grouping = &GroupingInfo{"ROLLUP", [][]string{cols}}
+	return s
+}
+
+// Cube specifies a CUBE construct following the GROUP BY columns.
+// Column names will be properly quoted.
+func (s *SelectQuery) Cube(cols ...string) *SelectQuery {
+	s.grouping = &GroupingInfo{"CUBE", [][]string{cols}}
+	return s
+}
+
+// GroupingSets specifies a GROUPING SETS construct following the GROUP BY columns.
+// Each set lists the columns to be grouped by. An empty set represents the grand total.
+// Column names will be properly quoted.
+func (s *SelectQuery) GroupingSets(sets ...[]string) *SelectQuery {
+	s.grouping = &GroupingInfo{"GROUPING SETS", sets}
+	return s
+}
+
 // Having specifies the HAVING clause.
 func (s *SelectQuery) Having(e Expression) *SelectQuery {
 	s.having = e
@@ -245,21 +408,137 @@ func (s *SelectQuery) AndBind(params Params) *SelectQuery {
 	return s
 }
 
+// Clone returns a deep copy of the query.
+// The clauses and the parameters of the copy can be modified without affecting the original query,
+// which allows a base query to be shared and reused for building different queries.
+func (s *SelectQuery) Clone() *SelectQuery {
+	q := *s
+	q.distinctOn = append([]string{}, s.distinctOn...)
+	q.with = append([]string{}, s.with...)
+	q.indexHints = make([]IndexHint, len(s.indexHints))
+	for i, hint := range s.indexHints {
+		q.indexHints[i] = IndexHint{hint.Table, hint.Type, append([]string{}, hint.Indexes...)}
+	}
+	q.selects = make([]interface{}, len(s.selects))
+	for i, col := range s.selects {
+		if e, ok := col.(*selectExp); ok {
+			col = &selectExp{cloneExp(e.exp), e.alias}
+		}
+		q.selects[i] = col
+	}
+	q.from = append([]string{}, s.from...)
+	q.where = cloneExp(s.where)
+	q.join = make([]JoinInfo, len(s.join))
+	for i, join := range s.join {
+		q.join[i] = JoinInfo{join.Join, join.Table, cloneExp(join.On)}
+	}
+	q.orderBy = append([]string{}, s.orderBy...)
+	q.groupBy = append([]string{}, s.groupBy...)
+	if s.grouping != nil {
+		sets := make([][]string, len(s.grouping.Sets))
+		for i, set := range s.grouping.Sets {
+			sets[i] = append([]string{}, set...)
+		}
+		q.grouping = &GroupingInfo{s.grouping.Type, sets}
+	}
+	q.having = cloneExp(s.having)
+	q.union = make([]UnionInfo, len(s.union))
+	for i, union := range s.union {
+		q.union[i] = UnionInfo{union.All, union.Query.Clone()}
+	}
+	q.params = Params{}
+	for k, v := range s.params {
+		q.params[k] = v
+	}
+	return &q
+}
+
 // Build builds the SELECT query and returns an executable Query object.
 func (s *SelectQuery) Build() *Query {
 	params := Params{}
+	sql, err := s.build(s.builder, s.db, params)
+	q := s.builder.NewQuery(sql).Bind(params)
+	q.StrictMode = s.StrictMode
+	if err != nil {
+		q.LastError = err
+	}
+	return q
+}
+
+// AsExp returns an expression representing the SELECT query as a subquery.
+// The expression can be used wherever an Expression is accepted. For example,
+// Exists(q.AsExp()), In("id", q.AsExp()), HashExp{"id": q.AsExp()}.
+//
+// SelectQuery itself does not implement Expression because its Build method already builds
+// a Query and cannot also take the Expression.Build signature.
+func (s *SelectQuery) AsExp() *SubQueryExp {
+	return &SubQueryExp{s}
+}
+
+// build generates the SELECT statement using the given builder and DB.
+// The parameters to be bound to the statement will be added to params.
+func (s *SelectQuery) build(b Builder, db *DB, params Params) (string, error) {
+	if s.lastError != nil {
+		return "", s.lastError
+	}
+
+	qb := b.QueryBuilder()
+	groupBy := qb.BuildGroupBy(s.groupBy)
+	if s.grouping != nil {
+		var err error
+		if groupBy, err = buildGrouping(b, db, s.groupBy, s.grouping); err != nil {
+			return "", err
+		}
+	}
+
+	distinct, option := s.distinct, s.selectOption
+	if len(s.distinctOn) > 0 {
+		on, err := buildDistinctOn(b, s.distinctOn)
+		if err != nil {
+			return "", err
+		}
+		distinct = false
+		if option != "" {
+			option = on + " " + option
+		} else {
+			option = on
+		}
+	}
+
+	joins := make([]JoinInfo, len(s.join))
+	for i, join := range s.join {
+		typ, err := buildJoinType(b, join)
+		if err != nil {
+			return "", err
+		}
+		joins[i] = JoinInfo{typ, join.Table, join.On}
+	}
+	from, err := s.hintTables(b, db, joins)
+	if err != nil {
+		return "", err
+	}
+
 	for k, v := range s.params {
 		params[k] = v
 	}
-
-	qb := s.builder.QueryBuilder()
+	db.beginBuild(params)
+
+	selects := make([]string, len(s.selects))
+	for i, col := range s.selects {
+		switch col := col.(type) {
+		case string:
+			selects[i] = col
+		case *selectExp:
+			selects[i] = col.build(db, params)
+		}
+	}
 
 	clauses := []string{
-		qb.BuildSelect(s.selects, s.distinct, s.selectOption),
-		qb.BuildFrom(s.from),
-		qb.BuildJoin(s.join, params),
+		qb.BuildSelect(selects, distinct, option),
+		qb.BuildFrom(from),
+		qb.BuildJoin(joins, params),
 		qb.BuildWhere(s.where, params),
-		qb.BuildGroupBy(s.groupBy),
+		groupBy,
 		qb.BuildHaving(s.having, params),
 	}
 	sql := ""
@@ -276,8 +555,77 @@ func (s *SelectQuery) Build() *Query {
 	if union := qb.BuildUnion(s.union, params); union != "" {
 		sql = fmt.Sprintf("(%v) %v", sql, union)
 	}
+	if err := db.endBuild(params); err != nil {
+		return "", err
+	}
+	return sql, nil
+}
+
+// hintTables returns the FROM tables with the index hints appended to the hinted tables.
+// The tables of the given JOIN clauses are hinted in place.
+// A hinted table is quoted in advance so that the query builder keeps it as is.
+func (s *SelectQuery) hintTables(b Builder, db *DB, joins []JoinInfo) ([]string, error) {
+	if len(s.indexHints) == 0 {
+		return s.from, nil
+	}
+
+	hints := map[string]string{}
+	for _, hint := range s.indexHints {
+		sql, err := buildIndexHint(b, hint)
+		if err != nil {
+			return nil, err
+		}
+		if h, ok := hints[hint.Table]; ok {
+			sql = h + " " + sql
+		}
+		hints[hint.Table] = sql
+	}
 
-	return s.builder.NewQuery(sql).Bind(params)
+	used := map[string]bool{}
+	hintTable := func(table string) string {
+		name := table
+		if matches := selectRegex.FindStringSubmatch(table); len(matches) > 0 {
+			name = matches[1]
+		}
+		hint, ok := hints[name]
+		if !ok {
+			if hint, ok = hints[table]; !ok {
+				return table
+			}
+			name = table
+		}
+		used[name] = true
+		return quoteTableNameAndAlias(db, table) + " " + hint
+	}
+
+	from := make([]string, len(s.from))
+	for i, table := range s.from {
+		from[i] = hintTable(table)
+	}
+	for i, join := range joins {
+		joins[i].Table = hintTable(join.Table)
+	}
+	for _, hint := range s.indexHints {
+		if !used[hint.Table] {
+			return nil, fmt.Errorf("the index hint on %q does not match any table in the FROM or JOIN clauses", hint.Table)
+		}
+	}
+	return from, nil
+}
+
+// selectExp represents an expression being selected as a column.
+type selectExp struct {
+	exp   Expression
+	alias string
+}
+
+// build converts the expression into a column of a SELECT clause.
+func (e *selectExp) build(db *DB, params Params) string {
+	sql := "(" + e.exp.Build(db, params) + ")"
+	if e.alias != "" {
+		sql += " AS " + db.QuoteSimpleColumnName(e.alias)
+	}
+	return sql
 }
 
 // One executes the SELECT query and populates the first row of the result into the specified variable.
@@ -288,20 +636,30 @@ func (s *SelectQuery) Build() *Query {
 //
 // Note that when the query has no rows in the result set, an sql.ErrNoRows will be returned.
 func (s *SelectQuery) One(a interface{}) error {
-	if len(s.from) == 0 {
-		if tableName := s.TableMapper(a); tableName != "" {
-			s.from = []string{tableName}
-		}
+	if err := s.withTable(a).Build().WithContext(s.ctx).One(a); err != nil {
+		return err
 	}
-	return s.Build().WithContext(s.ctx).One(a)
+	return s.loadRelations(a, 0)
+}
+
+// converters returns the converters registered in the DB associated with the query.
+func (s *SelectQuery) converters() map[reflect.Type]Converter {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Converters
 }
 
 // Model selects the row with the specified primary key and populates the model with the row data.
 //
 // The model variable should be a pointer to a struct. If the query does not specify a "from" clause,
 // it will use the model struct to determine which table to select data from. It will also use the model
-// to infer the name of the primary key column. Only simple primary key is supported. For composite primary keys,
-// please use Where() to specify the filtering condition.
+// to infer the name of the primary key column.
+//
+// For a composite primary key, the pk parameter can be a struct (or a pointer to a struct) having the primary key
+// fields, such as a model of the same type, a map indexed by the primary key field or column names, or a slice
+// listing the primary key values in the order of the primary key fields. CompositePKError is returned if
+// the composite primary key values cannot be determined from pk.
 func (s *SelectQuery) Model(pk, model interface{}) error {
 	t := reflect.TypeOf(model)
 	if t.Kind() == reflect.Ptr {
@@ -310,15 +668,19 @@ func (s *SelectQuery) Model(pk, model interface{}) error {
 	if t.Kind() != reflect.Struct {
 		return VarTypeError("must be a pointer to a struct")
 	}
-	si := getStructInfo(t, s.FieldMapper)
+	si := getStructInfo(t, s.FieldMapper, s.converters())
+	if len(si.pkNames) == 0 {
+		return MissingPKError
+	}
 	if len(si.pkNames) == 1 {
-		return s.AndWhere(HashExp{si.nameMap[si.pkNames[0]].dbName: pk}).One(model)
+		return s.Clone().AndWhere(HashExp{si.nameMap[si.pkNames[0]].dbName: pk}).One(model)
 	}
 
-	if len(si.pkNames) == 0 {
-		return MissingPKError
+	where, err := si.pkExp(pk, s.FieldMapper, s.converters())
+	if err != nil {
+		return err
 	}
-	return CompositePKError
+	return s.Clone().AndWhere(where).One(model)
 }
 
 // All executes the SELECT query and populates all rows of the result into a slice.
@@ -329,12 +691,63 @@ func (s *SelectQuery) Model(pk, model interface{}) error {
 // to be selected from by calling getTableName() which will return either the type name of the slice elements
 // or the TableName() method if the slice element implements the TableModel interface.
 func (s *SelectQuery) All(slice interface{}) error {
+	n := 0
+	if v := reflect.ValueOf(slice); v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Slice {
+		n = v.Elem().Len()
+	}
+	if err := s.withTable(slice).Build().WithContext(s.ctx).All(slice); err != nil {
+		return err
+	}
+	return s.loadRelations(slice, n)
+}
+
+// withTable returns a query selecting from the table associated with the given model or slice of models
+// if the query does not specify a "from" clause. Otherwise the query itself is returned.
+// If the model is soft-deletable, the returned query also filters the soft-deleted rows as specified by
+// WithDeleted and OnlyDeleted.
+func (s *SelectQuery) withTable(a interface{}) *SelectQuery {
 	if len(s.from) == 0 {
-		if tableName := s.TableMapper(slice); tableName != "" {
-			s.from = []string{tableName}
+		if tableName := s.TableMapper(a); tableName != "" {
+			q := s.Clone()
+			q.from = []string{tableName}
+			if s.deleted != withDeleted {
+				if t := modelType(a); t != nil {
+					si := getStructInfo(t, s.FieldMapper, s.converters())
+					if e := si.softDeleteExp(tableName, s.deleted == onlyDeleted); e != nil {
+						q.AndWhere(e)
+					}
+				}
+			}
+			return q
 		}
 	}
-	return s.Build().WithContext(s.ctx).All(slice)
+	return s
+}
+
+// WithDeleted makes the query also select the rows of a soft-deletable model which are soft deleted.
+// By default, when the query selects from the table associated with a model having a field tagged with
+// the "softDelete" option, the soft-deleted rows are filtered out.
+func (s *SelectQuery) WithDeleted() *SelectQuery {
+	s.deleted = withDeleted
+	return s
+}
+
+// OnlyDeleted makes the query only select the rows of a soft-deletable model which are soft deleted.
+func (s *SelectQuery) OnlyDeleted() *SelectQuery {
+	s.deleted = onlyDeleted
+	return s
+}
+
+// modelType returns the struct type of a model or a slice of models, or nil if it is not a struct.
+func modelType(a interface{}) reflect.Type {
+	t := reflect.TypeOf(a)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
 }
 
 // Rows builds and executes the SELECT query and returns a Rows object for data retrieval purpose.
@@ -355,3 +768,136 @@ func (s *SelectQuery) Row(a ...interface{}) error {
 func (s *SelectQuery) Column(a interface{}) error {
 	return s.Build().WithContext(s.ctx).Column(a)
 }
+
+// Paginate executes the SELECT query for the given 1-based page number and populates the rows of the page into a slice.
+// It also executes a COUNT query derived from the SELECT query to find out the total number of rows.
+// If perPage is not positive, all rows will be populated into the slice as a single page.
+//
+// The query itself is not modified by this method. Please refer to All() for how the slice should be given.
+func (s *SelectQuery) Paginate(slice interface{}, page, perPage int64) (*PageInfo, error) {
+	q := s.withTable(slice).Clone()
+	if page < 1 {
+		page = 1
+	}
+
+	info := &PageInfo{Page: page}
+	if err := q.countQuery().Row(&info.TotalCount); err != nil {
+		return nil, err
+	}
+	if perPage > 0 {
+		info.PerPage = perPage
+		info.PageCount = (info.TotalCount + perPage - 1) / perPage
+		q.limit = perPage
+		q.offset = (page - 1) * perPage
+	} else if info.TotalCount > 0 {
+		info.PageCount = 1
+	}
+
+	if err := q.All(slice); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Count returns the number of rows returned by the query, ignoring ORDER BY, LIMIT and OFFSET.
+// If the query selects a single column distinctively, the number of distinct values is returned.
+// The query itself is not modified by this method.
+func (s *SelectQuery) Count() (int64, error) {
+	var n int64
+	err := s.countQuery().Row(&n)
+	return n, err
+}
+
+// Sum returns the sum of the specified column values. The column name will be properly quoted.
+// ORDER BY, LIMIT and OFFSET are ignored, and the query itself is not modified by this method.
+// If the query uses GROUP BY, HAVING, DISTINCT or UNION, the column must be one of the selected columns.
+func (s *SelectQuery) Sum(col string) (float64, error) {
+	return s.float("SUM", col)
+}
+
+// Avg returns the average of the specified column values. The column name will be properly quoted.
+// ORDER BY, LIMIT and OFFSET are ignored, and the query itself is not modified by this method.
+// If the query uses GROUP BY, HAVING, DISTINCT or UNION, the column must be one of the selected columns.
+func (s *SelectQuery) Avg(col string) (float64, error) {
+	return s.float("AVG", col)
+}
+
+// Min populates the minimum of the specified column values into the given variable.
+// The column name will be properly quoted. ORDER BY, LIMIT and OFFSET are ignored, and the query itself
+// is not modified by this method. If the query uses GROUP BY, HAVING, DISTINCT or UNION, the column
+// must be one of the selected columns. Note that the result is NULL if the query returns no row.
+func (s *SelectQuery) Min(col string, a interface{}) error {
+	return s.aggregateQuery("MIN(" + s.db.QuoteColumnName(col) + ")").Row(a)
+}
+
+// Max populates the maximum of the specified column values into the given variable.
+// The column name will be properly quoted. ORDER BY, LIMIT and OFFSET are ignored, and the query itself
+// is not modified by this method. If the query uses GROUP BY, HAVING, DISTINCT or UNION, the column
+// must be one of the selected columns. Note that the result is NULL if the query returns no row.
+func (s *SelectQuery) Max(col string, a interface{}) error {
+	return s.aggregateQuery("MAX(" + s.db.QuoteColumnName(col) + ")").Row(a)
+}
+
+// Exists returns a value indicating whether the query returns any row.
+// The query itself is not modified by this method.
+func (s *SelectQuery) Exists() (bool, error) {
+	q := s.Clone()
+	q.orderBy = nil
+	sub := q.Build()
+
+	var exists bool
+	query := s.builder.NewQuery(buildExists(s.builder, sub.sql)).Bind(sub.params).WithContext(s.ctx)
+	query.LastError = sub.LastError
+	err := query.Row(&exists)
+	return exists, err
+}
+
+// float returns the float result of applying the named aggregate function to the specified column.
+// Zero is returned if the aggregate function returns NULL.
+func (s *SelectQuery) float(fn, col string) (float64, error) {
+	var v sql.NullFloat64
+	err := s.aggregateQuery(fn + "(" + s.db.QuoteColumnName(col) + ")").Row(&v)
+	return v.Float64, err
+}
+
+// countQuery returns a query that counts the rows returned by this query, ignoring ORDER BY, LIMIT and OFFSET.
+func (s *SelectQuery) countQuery() *SelectQuery {
+	if s.distinct && len(s.selects) == 1 && s.isSimple(false) {
+		col, ok := s.selects[0].(string)
+		if ok && !selectRegex.MatchString(col) && !strings.HasSuffix(col, "*") {
+			q := s.Clone()
+			q.distinct = false
+			return q.aggregateQuery("COUNT(DISTINCT " + s.db.QuoteColumnName(col) + ")")
+		}
+	}
+	return s.aggregateQuery("COUNT(*)")
+}
+
+// aggregateQuery returns a query that selects the given aggregate expression from the rows returned by this
+// query, ignoring ORDER BY, LIMIT and OFFSET. The query is wrapped as a subquery if it uses GROUP BY, HAVING,
+// DISTINCT or UNION.
+func (s *SelectQuery) aggregateQuery(exp string) *SelectQuery {
+	q := s.Clone()
+	q.orderBy = nil
+	q.limit = -1
+	q.offset = -1
+	if q.isSimple(true) {
+		q.selects = []interface{}{exp}
+		return q
+	}
+
+	sub := q.Build()
+	q = NewSelectQuery(s.builder, s.db).
+		WithContext(s.ctx).
+		Select(exp).
+		From("(" + sub.sql + ") c").
+		Bind(sub.params)
+	q.lastError = sub.LastError
+	return q
+}
+
+// isSimple returns whether the query does not use GROUP BY, HAVING or UNION.
+// If distinct is true, the query must not select columns distinctively either.
+func (s *SelectQuery) isSimple(distinct bool) bool {
+	return len(s.groupBy) == 0 && s.grouping == nil && s.having == nil && len(s.union) == 0 && !(distinct && (s.distinct || len(s.distinctOn) > 0))
+}
@@ -6,6 +6,7 @@ package dbx
 
 import (
 	"testing"
+	"time"
 
 	"database/sql"
 
@@ -61,6 +62,153 @@ func TestSelectQuery(t *testing.T) {
 	assert.Equal(t, q.SQL(), expected, "t5")
 }
 
+func TestSelectQuery_AndSelectExp(t *testing.T) {
+	db := getDB()
+
+	sub := db.Select("COUNT(*)").From("order").Where(NewExp("order.customer_id=customer.id"))
+	q := db.Select("id").
+		AndSelectExp(sub.AsExp(), "orders").
+		AndSelect("name").
+		AndSelectExp(NewExp("{:x}+1", Params{"x": 2}), "").
+		From("customer").
+		Where(In("id", db.Select("customer_id").From("order").Where(HashExp{"total": 40}).AsExp())).
+		Build()
+	expected := "SELECT `id`, (SELECT COUNT(*) FROM `order` WHERE order.customer_id=customer.id) AS `orders`, `name`, ({:x}+1) FROM `customer` WHERE `id` IN (SELECT `customer_id` FROM `order` WHERE `total`={:p1})"
+	assert.Equal(t, expected, q.SQL())
+	assert.Equal(t, Params{"x": 2, "p1": 40}, q.Params())
+}
+
+func TestSelectQuery_Grouping(t *testing.T) {
+	db := getDB()
+	db.Builder = NewPgsqlBuilder(db, nil)
+
+	q := db.Select("region", "year", "SUM(amount) AS total").
+		AndSelectExp(Grouping("region", "year"), "level").
+		From("sales").
+		GroupBy("region").
+		Rollup("year")
+	assert.Equal(t, `SELECT "region", "year", SUM(amount) AS "total", (GROUPING("region", "year")) AS "level" FROM "sales" GROUP BY "region", ROLLUP ("year")`, q.Build().SQL())
+
+	q = db.Select().From("sales").Cube("region", "year")
+	assert.Equal(t, `SELECT * FROM "sales" GROUP BY CUBE ("region", "year")`, q.Build().SQL())
+
+	q = db.Select().From("sales").GroupingSets([]string{"region"}, []string{})
+	assert.Equal(t, `SELECT * FROM "sales" GROUP BY GROUPING SETS (("region"), ())`, q.Build().SQL())
+	cq := q.countQuery().Build()
+	assert.Equal(t, `SELECT COUNT(*) FROM (SELECT * FROM "sales" GROUP BY GROUPING SETS (("region"), ())) "c"`, cq.SQL())
+
+	assert.NotNil(t, db.Select().From("sales").Rollup().Build().LastError)
+	assert.NotNil(t, db.Select().From("sales").Cube().Build().LastError)
+	assert.NotNil(t, db.Select().From("sales").GroupingSets().Build().LastError)
+	assert.Nil(t, db.Select().From("sales").GroupingSets([]string{}).Build().LastError)
+
+	db.Builder = NewSqliteBuilder(db, nil)
+	q = db.Select().From("sales").Rollup("year")
+	assert.NotNil(t, q.Build().LastError)
+	assert.NotNil(t, q.countQuery().Build().LastError)
+	_, err := q.Exists()
+	assert.NotNil(t, err)
+	outer := db.Select().From("users").Where(In("id", q.AsExp()))
+	assert.NotNil(t, outer.Build().LastError)
+	_, err = outer.Exists()
+	assert.NotNil(t, err)
+	assert.NotNil(t, db.Select().From("users").Where(Exists(db.Select().From("t").Where(In("id", q.AsExp())).AsExp())).Build().LastError)
+	assert.NotNil(t, db.Update("users", Params{"status": 1}, In("id", q.AsExp())).LastError)
+	assert.NotNil(t, db.Insert("users", Params{"id": q.AsExp()}).LastError)
+	params := Params{}
+	assert.Equal(t, "", q.AsExp().Build(db, params))
+	assert.Empty(t, params)
+	assert.Empty(t, db.builds.builds)
+}
+
+func TestSelectQuery_DistinctOn(t *testing.T) {
+	db := getDB()
+	db.Builder = NewPgsqlBuilder(db, nil)
+
+	q := db.Select("id", "name").Distinct(true).DistinctOn("name").From("users").OrderBy("name", "id DESC")
+	assert.Equal(t, `SELECT DISTINCT ON ("name") "id", "name" FROM "users" ORDER BY "name", "id" DESC`, q.Build().SQL())
+	cq := q.countQuery().Build()
+	assert.Equal(t, `SELECT COUNT(*) FROM (SELECT DISTINCT ON ("name") "id", "name" FROM "users") "c"`, cq.SQL())
+
+	db.Builder = NewMysqlBuilder(db, nil)
+	assert.NotNil(t, db.Select().DistinctOn("name").From("users").Build().LastError)
+}
+
+func TestSelectQuery_IndexHint(t *testing.T) {
+	db := getDB()
+	db.Builder = NewMysqlBuilder(db, nil)
+
+	q := db.Select().From("users u", "profile").
+		InnerJoin("orders", NewExp("orders.user_id=u.id")).
+		UseIndex("u", "idx_name").
+		IgnoreIndex("u", "idx_email").
+		ForceIndex("orders", "idx_user")
+	expected := "SELECT * FROM `users` `u` USE INDEX (`idx_name`) IGNORE INDEX (`idx_email`), `profile` INNER JOIN `orders` FORCE INDEX (`idx_user`) ON orders.user_id=u.id"
+	assert.Equal(t, expected, q.Build().SQL())
+	assert.Equal(t, expected, q.Clone().Build().SQL())
+	q = db.Select().From("users u").UseIndex("user", "idx_name")
+	assert.EqualError(t, q.Build().LastError, `the index hint on "user" does not match any table in the FROM or JOIN clauses`)
+
+	db.Builder = NewMssqlBuilder(db, nil)
+	q = db.Select().From("users").UseIndex("users", "idx_name")
+	assert.Equal(t, "SELECT * FROM [users] WITH (INDEX([idx_name]))\nORDER BY (SELECT NULL)\nOFFSET 0 ROWS", q.Build().SQL())
+	q.IgnoreIndex("users", "idx_email")
+	assert.NotNil(t, q.Build().LastError)
+
+	db.Builder = NewSqliteBuilder(db, nil)
+	assert.NotNil(t, db.Select().From("users").UseIndex("users", "idx_name").Build().LastError)
+}
+
+func TestSelectQuery_Joins(t *testing.T) {
+	db := getDB()
+	db.Builder = NewPgsqlBuilder(db, nil)
+
+	q := db.Select().From("users u").
+		FullJoin("profile p", NewExp("p.user_id=u.id")).
+		CrossJoin("settings").
+		NaturalJoin("roles").
+		JoinUsing("LEFT JOIN", "orders", "user_id").
+		LateralJoin("(SELECT * FROM posts WHERE user_id=u.id LIMIT 1) lp").
+		LeftLateralJoin("(SELECT * FROM comments WHERE user_id=u.id LIMIT 1) lc", nil)
+	expected := `SELECT * FROM "users" "u" FULL JOIN "profile" "p" ON p.user_id=u.id CROSS JOIN "settings" NATURAL JOIN "roles" LEFT JOIN "orders" USING ("user_id") CROSS JOIN LATERAL (SELECT * FROM posts WHERE user_id=u.id LIMIT 1) "lp" LEFT JOIN LATERAL (SELECT * FROM comments WHERE user_id=u.id LIMIT 1) "lc" ON TRUE`
+	assert.Equal(t, expected, q.Build().SQL())
+	assert.Equal(t, expected, q.Clone().Build().SQL())
+
+	db.Builder = NewMssqlBuilder(db, nil)
+	q = db.Select().From("users u").
+		LateralJoin("(SELECT TOP 1 * FROM posts WHERE user_id=u.id) lp").
+		LeftLateralJoin("(SELECT TOP 1 * FROM comments WHERE user_id=u.id) lc", nil)
+	assert.Equal(t, "SELECT * FROM [users] [u] CROSS APPLY (SELECT TOP 1 * FROM posts WHERE user_id=u.id) [lp] OUTER APPLY (SELECT TOP 1 * FROM comments WHERE user_id=u.id) [lc]\nORDER BY (SELECT NULL)\nOFFSET 0 ROWS", q.Build().SQL())
+	assert.NotNil(t, db.Select().From("users").JoinUsing("INNER JOIN", "orders", "user_id").Build().LastError)
+
+	db.Builder = NewMysqlBuilder(db, nil)
+	assert.NotNil(t, db.Select().From("users").FullJoin("orders", nil).Build().LastError)
+}
+
+func TestSelectQuery_SoftDelete(t *testing.T) {
+	db := getDB()
+
+	type Post struct {
+		ID        int
+		DeletedAt *time.Time `db:"deleted_at,softDelete"`
+	}
+
+	var posts []Post
+	q := db.Select().Where(HashExp{"id": 1})
+	assert.Equal(t, "SELECT * FROM `post` WHERE (`id`={:p0}) AND (`post`.`deleted_at` IS NULL)", q.withTable(&posts).Build().SQL())
+	assert.Equal(t, "SELECT * FROM `post` WHERE (`id`={:p0}) AND (NOT (`post`.`deleted_at` IS NULL))", q.OnlyDeleted().withTable(&posts).Build().SQL())
+	assert.Equal(t, "SELECT * FROM `post` WHERE `id`={:p0}", q.WithDeleted().withTable(&posts).Build().SQL())
+	assert.Equal(t, HashExp{"id": 1}, q.where)
+
+	// soft-deleted rows are not filtered when selecting from an explicit table
+	q = db.Select().From("post p")
+	assert.Equal(t, "SELECT * FROM `post` `p`", q.withTable(&posts).Build().SQL())
+
+	// non soft-deletable models
+	var customers []Customer
+	assert.Equal(t, "SELECT * FROM `customer`", db.Select().withTable(&customers).Build().SQL())
+}
+
 func TestSelectQuery_Data(t *testing.T) {
 	db := getPreparedDB()
 	defer db.Close()
@@ -159,3 +307,141 @@ func TestSelectQuery_Model(t *testing.T) {
 		assert.Equal(t, CompositePKError, err)
 	}
 }
+
+func TestSelectQuery_countQuery(t *testing.T) {
+	db := getDB()
+
+	q := db.Select("id", "name").From("users").Where(HashExp{"status": 1}).OrderBy("id").Limit(10).Offset(20)
+	cq := q.countQuery().Build()
+	assert.Equal(t, "SELECT COUNT(*) FROM `users` WHERE `status`={:p0}", cq.SQL())
+	assert.Equal(t, Params{"p0": 1}, cq.Params())
+	assert.Equal(t, []string{"id"}, q.orderBy)
+	assert.Equal(t, int64(10), q.limit)
+
+	cq = db.Select("status").From("users").Where(HashExp{"status": 1}).GroupBy("status").countQuery().Build()
+	assert.Equal(t, "SELECT COUNT(*) FROM (SELECT `status` FROM `users` WHERE `status`={:p0} GROUP BY `status`) `c`", cq.SQL())
+	assert.Equal(t, Params{"p0": 1}, cq.Params())
+
+	cq = db.Select("status").Distinct(true).From("users").countQuery().Build()
+	assert.Equal(t, "SELECT COUNT(DISTINCT `status`) FROM `users`", cq.SQL())
+
+	cq = db.Select("status", "name").Distinct(true).From("users").countQuery().Build()
+	assert.Equal(t, "SELECT COUNT(*) FROM (SELECT DISTINCT `status`, `name` FROM `users`) `c`", cq.SQL())
+
+	cq = db.Select().From("users").Union(db.Select().From("posts").Build()).countQuery().Build()
+	assert.Equal(t, "SELECT COUNT(*) FROM ((SELECT * FROM `users`) UNION (SELECT * FROM `posts`)) `c`", cq.SQL())
+}
+
+func TestSelectQuery_Paginate(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	q := db.Select().OrderBy("id")
+
+	var customers []Customer
+	info, err := q.Paginate(&customers, 2, 2)
+	if assert.Nil(t, err) {
+		assert.Equal(t, &PageInfo{Page: 2, PerPage: 2, TotalCount: 3, PageCount: 2}, info)
+		if assert.Equal(t, 1, len(customers)) {
+			assert.Equal(t, 3, customers[0].ID)
+		}
+	}
+	assert.Equal(t, 0, len(q.from))
+
+	customers = nil
+	info, err = q.Paginate(&customers, 0, 0)
+	if assert.Nil(t, err) {
+		assert.Equal(t, &PageInfo{Page: 1, PerPage: 0, TotalCount: 3, PageCount: 1}, info)
+		assert.Equal(t, 3, len(customers))
+	}
+
+	var statuses []NullStringMap
+	info, err = db.Select("status").From("customer").GroupBy("status").Paginate(&statuses, 1, 1)
+	if assert.Nil(t, err) {
+		assert.Equal(t, &PageInfo{Page: 1, PerPage: 1, TotalCount: 2, PageCount: 2}, info)
+		assert.Equal(t, 1, len(statuses))
+	}
+}
+
+func TestSelectQuery_Aggregate(t *testing.T) {
+	db := getPreparedDB()
+	defer db.Close()
+
+	q := db.Select("id", "status").From("customer").Where(NewExp("id>1")).OrderBy("id").Limit(1)
+
+	n, err := q.Count()
+	if assert.Nil(t, err) {
+		assert.Equal(t, int64(2), n)
+	}
+	assert.Equal(t, []interface{}{"id", "status"}, q.selects)
+
+	n, err = db.Select("status").Distinct(true).From("customer").Count()
+	if assert.Nil(t, err) {
+		assert.Equal(t, int64(2), n)
+	}
+
+	sum, err := q.Sum("status")
+	if assert.Nil(t, err) {
+		assert.Equal(t, float64(3), sum)
+	}
+	avg, err := q.Avg("status")
+	if assert.Nil(t, err) {
+		assert.Equal(t, 1.5, avg)
+	}
+	sum, err = db.Select().From("customer").Where(NewExp("id>10")).Sum("status")
+	if assert.Nil(t, err) {
+		assert.Equal(t, float64(0), sum)
+	}
+
+	var min, max string
+	if assert.Nil(t, q.Min("email", &min)) {
+		assert.Equal(t, "user2@example.com", min)
+	}
+	if assert.Nil(t, q.Max("email", &max)) {
+		assert.Equal(t, "user3@example.com", max)
+	}
+
+	sum, err = db.Select("status").From("customer").GroupBy("status").Sum("status")
+	if assert.Nil(t, err) {
+		assert.Equal(t, float64(3), sum)
+	}
+
+	exists, err := q.Exists()
+	if assert.Nil(t, err) {
+		assert.True(t, exists)
+	}
+	exists, err = db.Select().From("customer").Where(HashExp{"id": 100}).Exists()
+	if assert.Nil(t, err) {
+		assert.False(t, exists)
+	}
+}
+
+func TestSelectQuery_Clone(t *testing.T) {
+	db := getDB()
+
+	base := db.Select("id").
+		From("users").
+		Where(HashExp{"status": 1}).
+		InnerJoin("profile", HashExp{"profile.type": 2}).
+		Having(NewExp("id>{:min}", Params{"min": 1})).
+		Union(db.NewQuery("SELECT id FROM admins WHERE id={:id}").Bind(Params{"id": 3})).
+		OrderBy("id").
+		Bind(Params{"x": 1})
+	expected := base.Build().SQL()
+
+	q := base.Clone().
+		AndSelect("name").
+		AndWhere(HashExp{"type": 2}).
+		AndHaving(NewExp("id<10")).
+		AndOrderBy("name").
+		AndGroupBy("id").
+		AndBind(Params{"y": 2})
+	q.where.(*AndOrExp).exps[0].(HashExp)["status"] = 2
+	q.join[0].On.(HashExp)["profile.type"] = 3
+	q.union[0].Query.Bind(Params{"id": 4})
+	q.having.(*AndOrExp).exps[0].(*Exp).params["min"] = 2
+
+	assert.NotEqual(t, expected, q.Build().SQL())
+	assert.Equal(t, expected, base.Build().SQL())
+	assert.Equal(t, Params{"x": 1, "min": 1, "id": 3, "p1": 2, "p2": 1}, base.Build().Params())
+}
@@ -0,0 +1,73 @@
+// Copyright 2016 Qiang Xue. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package dbx
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// Snapshot enables dirty tracking for a model struct when it is embedded in the struct.
+//
+// A snapshot of the field values of the model is taken when the model is populated by Query.One, Query.All,
+// Rows.ScanStruct or SelectQuery.Model, after its AfterFind hook is called if it has one. A snapshot is also
+// taken after the model is inserted or updated by ModelQuery, or explicitly by calling ModelQuery.Snapshot.
+// ModelQuery.Update then only updates the fields changed since the snapshot unless the fields to be updated
+// are explicitly specified.
+type Snapshot struct {
+	values map[string]interface{} // the field values indexed by the field names
+}
+
+// tracker is implemented by the models embedding Snapshot.
+type tracker interface {
+	snapshot() *Snapshot
+}
+
+func (s *Snapshot) snapshot() *Snapshot {
+	return s
+}
+
+// takeSnapshot saves the field values of the given struct value into its snapshot if it embeds Snapshot.
+// The struct value must be addressable.
+func takeSnapshot(si *structInfo, v reflect.Value) {
+	t, ok := v.Addr().Interface().(tracker)
+	if !ok {
+		return
+	}
+	values := make(map[string]interface{}, len(si.nameMap))
+	for name, fi := range si.nameMap {
+		values[name] = snapshotValue(fi, v)
+	}
+	t.snapshot().values = values
+}
+
+// changedFields returns the sorted names of the fields whose values differ from those in the snapshot.
+// All fields are considered changed if no snapshot has been taken.
+func changedFields(si *structInfo, v reflect.Value, s *Snapshot) []string {
+	var names []string
+	for name, fi := range si.nameMap {
+		if old, ok := s.values[name]; !ok || !reflect.DeepEqual(old, snapshotValue(fi, v)) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// snapshotValue returns a copy of the field value to be kept in a snapshot, so that it is not affected by
+// in-place changes of the field. The value of a field tagged with the "json" option is kept as encoded JSON.
+func snapshotValue(fi *fieldInfo, v reflect.Value) interface{} {
+	value := fi.getValue(v)
+	if b, ok := value.([]byte); ok {
+		return append([]byte(nil), b...)
+	}
+	if value != nil && fi.hasOption("json") {
+		if b, err := json.Marshal(value); err == nil {
+			return string(b)
+		}
+	}
+	return value
+}
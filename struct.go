@@ -6,10 +6,14 @@ package dbx
 
 import (
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"reflect"
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 )
 
 type (
@@ -20,9 +24,10 @@ type (
 	TableMapFunc func(a interface{}) string
 
 	structInfo struct {
-		nameMap   map[string]*fieldInfo // mapping from struct field names to field infos
-		dbNameMap map[string]*fieldInfo // mapping from db column names to field infos
-		pkNames   []string              // struct field names representing PKs
+		nameMap   map[string]*fieldInfo    // mapping from struct field names to field infos
+		dbNameMap map[string]*fieldInfo    // mapping from db column names to field infos
+		pkNames   []string                 // struct field names representing PKs
+		relations map[string]*relationInfo // mapping from struct field names to relations
 	}
 
 	structValue struct {
@@ -32,14 +37,18 @@ type (
 	}
 
 	fieldInfo struct {
-		name   string // field name
-		dbName string // db column name
-		path   []int  // index path to the struct field reflection
+		name    string            // field name
+		dbName  string            // db column name
+		path    []int             // index path to the struct field reflection
+		typ     reflect.Type      // field type
+		options map[string]string // options specified in the db tag
 	}
 
 	structInfoMapKey struct {
 		t reflect.Type
 		m reflect.Value
+		c reflect.Value // the converters affecting which struct fields are columns
+		n int           // the number of the converters, so that registering a converter invalidates the entry
 	}
 )
 
@@ -49,6 +58,7 @@ var (
 
 	fieldRegex      = regexp.MustCompile(`([^A-Z_])([A-Z])`)
 	scannerType     = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	valuerType      = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
 	structInfoMap   = make(map[structInfoMapKey]*structInfo)
 	muStructInfoMap sync.Mutex
 )
@@ -61,11 +71,11 @@ func DefaultFieldMapFunc(f string) string {
 	return strings.ToLower(fieldRegex.ReplaceAllString(f, "${1}_$2"))
 }
 
-func getStructInfo(a reflect.Type, mapper FieldMapFunc) *structInfo {
+func getStructInfo(a reflect.Type, mapper FieldMapFunc, converters map[reflect.Type]Converter) *structInfo {
 	muStructInfoMap.Lock()
 	defer muStructInfoMap.Unlock()
 
-	key := structInfoMapKey{a, reflect.ValueOf(mapper)}
+	key := structInfoMapKey{a, reflect.ValueOf(mapper), reflect.ValueOf(converters), len(converters)}
 	if si, ok := structInfoMap[key]; ok {
 		return si
 	}
@@ -73,21 +83,22 @@ func getStructInfo(a reflect.Type, mapper FieldMapFunc) *structInfo {
 	si := &structInfo{
 		nameMap:   map[string]*fieldInfo{},
 		dbNameMap: map[string]*fieldInfo{},
+		relations: map[string]*relationInfo{},
 	}
-	si.build(a, make([]int, 0), "", "", mapper)
+	si.build(a, make([]int, 0), "", "", mapper, converters)
 	structInfoMap[key] = si
 
 	return si
 }
 
-func newStructValue(model interface{}, fieldMapFunc FieldMapFunc, tableMapFunc TableMapFunc) *structValue {
+func newStructValue(model interface{}, fieldMapFunc FieldMapFunc, converters map[reflect.Type]Converter, tableMapFunc TableMapFunc) *structValue {
 	value := reflect.ValueOf(model)
 	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct || value.IsNil() {
 		return nil
 	}
 
 	return &structValue{
-		structInfo: getStructInfo(reflect.TypeOf(model).Elem(), fieldMapFunc),
+		structInfo: getStructInfo(reflect.TypeOf(model).Elem(), fieldMapFunc, converters),
 		value:      value.Elem(),
 		tableName:  tableMapFunc(model),
 	}
@@ -101,17 +112,63 @@ func (s *structValue) pk() map[string]interface{} {
 	return s.columns(s.pkNames, nil)
 }
 
+// pkExp returns the condition matching the given values of a composite primary key.
+// The values can be given as a struct having the primary key fields, as a map indexed by the primary key
+// field or column names, or as a slice listing the values in the order of the primary key fields.
+func (si *structInfo) pkExp(pk interface{}, mapper FieldMapFunc, converters map[reflect.Type]Converter) (HashExp, error) {
+	v := reflect.Indirect(reflect.ValueOf(pk))
+	exp := HashExp{}
+	switch v.Kind() {
+	case reflect.Struct:
+		pi := getStructInfo(v.Type(), mapper, converters)
+		for _, name := range si.pkNames {
+			fi, ok := pi.nameMap[name]
+			if !ok {
+				return nil, CompositePKError
+			}
+			exp[si.nameMap[name].dbName] = fi.getValue(v)
+		}
+	case reflect.Map:
+		keyType := v.Type().Key()
+		if keyType.Kind() != reflect.String {
+			return nil, CompositePKError
+		}
+		for _, name := range si.pkNames {
+			fi := si.nameMap[name]
+			value := v.MapIndex(reflect.ValueOf(name).Convert(keyType))
+			if !value.IsValid() {
+				value = v.MapIndex(reflect.ValueOf(fi.dbName).Convert(keyType))
+			}
+			if !value.IsValid() {
+				return nil, CompositePKError
+			}
+			exp[fi.dbName] = value.Interface()
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Len() != len(si.pkNames) {
+			return nil, CompositePKError
+		}
+		for i, name := range si.pkNames {
+			exp[si.nameMap[name].dbName] = v.Index(i).Interface()
+		}
+	default:
+		return nil, CompositePKError
+	}
+	return exp, nil
+}
+
 // columns returns the struct field values indexed by their corresponding DB column names.
+// The values of the fields tagged with the "json" option are encoded as JSON when they are saved.
 func (s *structValue) columns(include, exclude []string) map[string]interface{} {
 	v := make(map[string]interface{}, len(s.nameMap))
 	if len(include) == 0 {
 		for _, fi := range s.nameMap {
-			v[fi.dbName] = fi.getValue(s.value)
+			v[fi.dbName] = fi.columnValue(s.value)
 		}
 	} else {
 		for _, attr := range include {
 			if fi, ok := s.nameMap[attr]; ok {
-				v[fi.dbName] = fi.getValue(s.value)
+				v[fi.dbName] = fi.columnValue(s.value)
 			}
 		}
 	}
@@ -125,6 +182,50 @@ func (s *structValue) columns(include, exclude []string) map[string]interface{}
 	return v
 }
 
+// writableColumns returns the values of the fields to be inserted, or updated if insert is false, indexed by
+// their DB column names. The fields are chosen in the same way as columns, except that the fields tagged with
+// "readonly" are never included, neither are the fields tagged with "insertonly" if insert is false.
+// The empty fields tagged with "omitempty" are not included unless they are listed in include.
+func (s *structValue) writableColumns(include, exclude []string, insert bool) map[string]interface{} {
+	v := s.columns(include, exclude)
+	for _, fi := range s.nameMap {
+		if _, ok := v[fi.dbName]; !ok {
+			continue
+		}
+		if fi.hasOption("readonly") || !insert && fi.hasOption("insertonly") {
+			delete(v, fi.dbName)
+		} else if fi.hasOption("omitempty") && !contains(include, fi.name) && isEmptyValue(reflect.ValueOf(v[fi.dbName])) {
+			delete(v, fi.dbName)
+		}
+	}
+	return v
+}
+
+// params returns the column values to be saved as Params. The values of the fields tagged with the "encrypted"
+// option are converted by the converters and encrypted using the cipher of the given DB, and the values of
+// the fields tagged with the "type" option are cast to the given column types.
+func (s *structValue) params(cols map[string]interface{}, db *DB) Params {
+	var converters map[reflect.Type]Converter
+	var c Cipher
+	if db != nil {
+		converters, c = db.Converters, db.Cipher
+	}
+	params := Params(cols)
+	for _, fi := range s.nameMap {
+		value, ok := params[fi.dbName]
+		if _, isExp := value.(Expression); ok && !isExp && fi.hasOption("encrypted") {
+			value = encryptedValue{value, c, converters}
+			params[fi.dbName] = value
+		}
+		if typ, _ := fi.option("type"); ok && typ != "" {
+			if _, isExp := value.(Expression); !isExp {
+				params[fi.dbName] = &castExp{value, typ}
+			}
+		}
+	}
+	return params
+}
+
 // getValue returns the field value for the given struct value.
 func (fi *fieldInfo) getValue(a reflect.Value) interface{} {
 	for _, i := range fi.path {
@@ -139,6 +240,75 @@ func (fi *fieldInfo) getValue(a reflect.Value) interface{} {
 	return a.Interface()
 }
 
+// columnValue returns the field value to be saved in the DB column for the given struct value.
+// The value of a field tagged with the "json" option is wrapped to be encoded as JSON, unless it is nil.
+func (fi *fieldInfo) columnValue(a reflect.Value) interface{} {
+	value := fi.getValue(a)
+	if value != nil && fi.hasOption("json") {
+		return jsonValue{value}
+	}
+	return value
+}
+
+// scanTarget returns the pointer to be passed to Rows.Scan for populating the field of the given struct value.
+// A field tagged with the "json" option is populated by decoding the column value as JSON, while a field
+// whose type has a converter registered in the DB is populated using the converter. The column value of
+// a field tagged with the "encrypted" option is decrypted by DB.Cipher first.
+func (fi *fieldInfo) scanTarget(a reflect.Value, db *DB) interface{} {
+	var converters map[reflect.Type]Converter
+	var c Cipher
+	if db != nil {
+		converters, c = db.Converters, db.Cipher
+	}
+	field := fi.getField(a)
+	var target interface{}
+	if fi.hasOption("json") {
+		target = jsonScanner{field}
+	} else {
+		target = scanTarget(field, converters)
+	}
+	if fi.hasOption("encrypted") {
+		return encryptedScanner{target, c}
+	}
+	return target
+}
+
+// jsonValue encodes a field value as JSON when it is saved in the database.
+type jsonValue struct {
+	value interface{}
+}
+
+// Value implements driver.Valuer.
+func (v jsonValue) Value() (driver.Value, error) {
+	b, err := json.Marshal(v.value)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// jsonScanner decodes a JSON column value into a field. The field is set to its zero value if the column is null.
+type jsonScanner struct {
+	field reflect.Value
+}
+
+// Scan implements sql.Scanner.
+func (s jsonScanner) Scan(src interface{}) error {
+	s.field.Set(reflect.Zero(s.field.Type()))
+	var data []byte
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	default:
+		return fmt.Errorf("cannot decode %T as JSON", src)
+	}
+	return json.Unmarshal(data, s.field.Addr().Interface())
+}
+
 // getField returns the reflection value of the field for the given struct value.
 func (fi *fieldInfo) getField(a reflect.Value) reflect.Value {
 	i := 0
@@ -148,7 +318,7 @@ func (fi *fieldInfo) getField(a reflect.Value) reflect.Value {
 	return a.Field(fi.path[i])
 }
 
-func (si *structInfo) build(a reflect.Type, path []int, namePrefix, dbNamePrefix string, mapper FieldMapFunc) {
+func (si *structInfo) build(a reflect.Type, path []int, namePrefix, dbNamePrefix string, mapper FieldMapFunc, converters map[reflect.Type]Converter) {
 	n := a.NumField()
 	for i := 0; i < n; i++ {
 		field := a.Field(i)
@@ -169,7 +339,7 @@ func (si *structInfo) build(a reflect.Type, path []int, namePrefix, dbNamePrefix
 		}
 
 		name := field.Name
-		dbName, isPK := parseTag(tag)
+		dbName, isPK, options := parseTag(tag)
 		if dbName == "" && !field.Anonymous {
 			if mapper != nil {
 				dbName = mapper(field.Name)
@@ -181,15 +351,33 @@ func (si *structInfo) build(a reflect.Type, path []int, namePrefix, dbNamePrefix
 			name = ""
 		}
 
-		if isNestedStruct(ft) {
+		if kind := relationKind(options); kind != "" && !field.Anonymous {
+			// relation field to be populated by SelectQuery.With
+			et := ft
+			if et.Kind() == reflect.Slice {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Ptr {
+				et = et.Elem()
+			}
+			fi := &fieldInfo{
+				name:    concat(namePrefix, name),
+				path:    path2,
+				typ:     field.Type,
+				options: options,
+			}
+			si.relations[fi.name] = &relationInfo{fi, kind, et}
+		} else if isNestedStruct(ft, converters) && !hasOption(options, "json") {
 			// dive into non-scanner struct
-			si.build(ft, path2, concat(namePrefix, name), concat(dbNamePrefix, dbName), mapper)
+			si.build(ft, path2, concat(namePrefix, name), concat(dbNamePrefix, dbName), mapper, converters)
 		} else if dbName != "" {
 			// non-anonymous scanner or struct field
 			fi := &fieldInfo{
-				name:   concat(namePrefix, name),
-				dbName: concat(dbNamePrefix, dbName),
-				path:   path2,
+				name:    concat(namePrefix, name),
+				dbName:  concat(dbNamePrefix, dbName),
+				path:    path2,
+				typ:     field.Type,
+				options: options,
 			}
 			// a field in an anonymous struct may be shadowed
 			if _, ok := si.nameMap[fi.name]; !ok || len(path2) < len(si.nameMap[fi.name].path) {
@@ -210,21 +398,111 @@ func (si *structInfo) build(a reflect.Type, path []int, namePrefix, dbNamePrefix
 	}
 }
 
-func isNestedStruct(t reflect.Type) bool {
+// isNestedStruct checks if a field type is a struct whose fields should be mapped to columns.
+// Scanners, time.Time and the types having converters are mapped to columns themselves. However, a struct
+// without exported fields is mapped to a column only if it has a converter or implements both driver.Valuer
+// and sql.Scanner, so that it can be both saved and populated. Other structs without exported fields,
+// such as sync.Mutex, are nested structs having no columns.
+func isNestedStruct(t reflect.Type, converters map[reflect.Type]Converter) bool {
 	if t.PkgPath() == "time" && t.Name() == "Time" {
 		return false
 	}
-	return t.Kind() == reflect.Struct && !reflect.PtrTo(t).Implements(scannerType)
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	if _, ok := converters[t]; ok {
+		return false
+	}
+	scanner := reflect.PtrTo(t).Implements(scannerType)
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.PkgPath == "" || f.Anonymous {
+			return !scanner
+		}
+	}
+	return !scanner || !reflect.PtrTo(t).Implements(valuerType)
+}
+
+// parseTag parses a db tag in the format of "[pk,]name[,option...]". It returns the column name,
+// whether the field is a primary key, and the options indexed by their names. An option may be given
+// as "name=value", or simply as "name" in which case its value is empty.
+func parseTag(tag string) (string, bool, map[string]string) {
+	parts := strings.Split(tag, ",")
+	isPK := parts[0] == "pk"
+	if isPK {
+		parts = parts[1:]
+	}
+	if len(parts) == 0 {
+		return "", isPK, nil
+	}
+	var options map[string]string
+	for _, option := range parts[1:] {
+		if options == nil {
+			options = map[string]string{}
+		}
+		if i := strings.Index(option, "="); i >= 0 {
+			options[option[:i]] = option[i+1:]
+		} else {
+			options[option] = ""
+		}
+	}
+	return parts[0], isPK, options
+}
+
+// option returns the value of the named tag option and whether the option is specified.
+func (fi *fieldInfo) option(name string) (string, bool) {
+	value, ok := fi.options[name]
+	return value, ok
 }
 
-func parseTag(tag string) (string, bool) {
-	if tag == "pk" {
-		return "", true
+// optionField returns the field tagged with the named option, or nil if there is no such field.
+func (si *structInfo) optionField(name string) *fieldInfo {
+	for _, fi := range si.nameMap {
+		if _, ok := fi.options[name]; ok {
+			return fi
+		}
 	}
-	if strings.HasPrefix(tag, "pk,") {
-		return tag[3:], true
+	return nil
+}
+
+// softDeleteExp returns the condition selecting the rows which are not soft deleted, or only those soft deleted
+// if deleted is true. The field tagged with "softDelete" stores the deletion time either as a nullable time
+// which is NULL for rows not deleted, or as a time.Time or an integer Unix timestamp which holds the zero value
+// for rows not deleted. The column name is prefixed with the table name if it is not empty.
+// Nil is returned if the struct is not soft-deletable.
+func (si *structInfo) softDeleteExp(table string, deleted bool) Expression {
+	fi := si.optionField("softDelete")
+	if fi == nil {
+		return nil
+	}
+	col := fi.dbName
+	if table != "" {
+		col = table + "." + col
+	}
+	var e Expression = HashExp{col: nil}
+	if fi.typ == timeType {
+		e = HashExp{col: time.Time{}}
+	} else if isIntegerKind(fi.typ.Kind()) {
+		e = HashExp{col: 0}
+	}
+	if deleted {
+		return Not(e)
+	}
+	return e
+}
+
+// hasOption checks if any of the named tag options is specified.
+func (fi *fieldInfo) hasOption(names ...string) bool {
+	return hasOption(fi.options, names...)
+}
+
+// hasOption checks if any of the named options is in the given tag options.
+func hasOption(options map[string]string, names ...string) bool {
+	for _, name := range names {
+		if _, ok := options[name]; ok {
+			return true
+		}
 	}
-	return tag, false
+	return false
 }
 
 func concat(s1, s2 string) string {
@@ -6,8 +6,10 @@ package dbx
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -43,17 +45,30 @@ func Test_concat(t *testing.T) {
 }
 
 func Test_parseTag(t *testing.T) {
-	name, pk := parseTag("abc")
+	name, pk, options := parseTag("abc")
 	assert.Equal(t, "abc", name)
 	assert.False(t, pk)
+	assert.Nil(t, options)
 
-	name, pk = parseTag("pk,abc")
+	name, pk, options = parseTag("pk,abc")
 	assert.Equal(t, "abc", name)
 	assert.True(t, pk)
+	assert.Nil(t, options)
 
-	name, pk = parseTag("pk")
+	name, pk, options = parseTag("pk")
 	assert.Equal(t, "", name)
 	assert.True(t, pk)
+	assert.Nil(t, options)
+
+	name, pk, options = parseTag("pk,,sequence=users_seq,default")
+	assert.Equal(t, "", name)
+	assert.True(t, pk)
+	assert.Equal(t, map[string]string{"sequence": "users_seq", "default": ""}, options)
+
+	name, pk, options = parseTag("abc,generator=uuid")
+	assert.Equal(t, "abc", name)
+	assert.False(t, pk)
+	assert.Equal(t, map[string]string{"generator": "uuid"}, options)
 }
 
 func Test_indirect(t *testing.T) {
@@ -74,7 +89,7 @@ func Test_structValue_columns(t *testing.T) {
 		Status: 2,
 		Email:  "abc@example.com",
 	}
-	sv := newStructValue(&customer, DefaultFieldMapFunc, GetTableName)
+	sv := newStructValue(&customer, DefaultFieldMapFunc, nil, GetTableName)
 	cols := sv.columns(nil, nil)
 	assert.Equal(t, map[string]interface{}{"id": 1, "name": "abc", "status": 2, "email": "abc@example.com", "address": sql.NullString{}}, cols)
 
@@ -87,11 +102,104 @@ func Test_structValue_columns(t *testing.T) {
 	cols = sv.columns(nil, []string{"ID", "Address"})
 	assert.Equal(t, map[string]interface{}{"name": "abc", "status": 2, "email": "abc@example.com"}, cols)
 
-	sv = newStructValue(&customer, nil, GetTableName)
+	sv = newStructValue(&customer, nil, nil, GetTableName)
 	cols = sv.columns([]string{"ID", "Name"}, []string{"ID"})
 	assert.Equal(t, map[string]interface{}{"Name": "abc"}, cols)
 }
 
+func Test_structValue_writableColumns(t *testing.T) {
+	type Account struct {
+		ID        int
+		Name      string
+		Email     string `db:"email,insertonly"`
+		Balance   int    `db:"balance,readonly"`
+		Status    int    `db:"status,omitempty"`
+		CreatedAt string `db:"created_at,omitempty,insertonly"`
+	}
+	account := Account{ID: 1, Name: "abc", Email: "abc@example.com", Balance: 10}
+	sv := newStructValue(&account, DefaultFieldMapFunc, nil, GetTableName)
+
+	cols := sv.writableColumns(nil, nil, true)
+	assert.Equal(t, map[string]interface{}{"id": 1, "name": "abc", "email": "abc@example.com"}, cols)
+	cols = sv.writableColumns(nil, nil, false)
+	assert.Equal(t, map[string]interface{}{"id": 1, "name": "abc"}, cols)
+	cols = sv.writableColumns([]string{"Balance", "Status", "CreatedAt"}, nil, true)
+	assert.Equal(t, map[string]interface{}{"status": 0, "created_at": ""}, cols)
+	cols = sv.writableColumns([]string{"Email", "Status"}, []string{"Status"}, false)
+	assert.Equal(t, map[string]interface{}{}, cols)
+
+	account.Status = 2
+	cols = sv.writableColumns(nil, []string{"ID"}, false)
+	assert.Equal(t, map[string]interface{}{"name": "abc", "status": 2}, cols)
+}
+
+func Test_structValue_params(t *testing.T) {
+	type Document struct {
+		ID   int
+		Data string `db:"data,type=jsonb"`
+	}
+	doc := Document{ID: 1, Data: "{}"}
+	sv := newStructValue(&doc, DefaultFieldMapFunc, nil, GetTableName)
+
+	params := sv.params(sv.columns(nil, nil), nil)
+	assert.Equal(t, Params{"id": 1, "data": &castExp{"{}", "jsonb"}}, params)
+	params = sv.params(map[string]interface{}{"data": NewExp("NULL")}, nil)
+	assert.Equal(t, Params{"data": NewExp("NULL")}, params)
+	params = sv.params(map[string]interface{}{"id": 2}, nil)
+	assert.Equal(t, Params{"id": 2}, params)
+
+	db := getDB()
+	q := db.Update("document", params, nil)
+	assert.Equal(t, "UPDATE `document` SET `id`={:p0}", q.SQL())
+	q = db.Insert("document", sv.params(sv.columns(nil, nil), nil))
+	assert.Equal(t, "INSERT INTO `document` (`data`, `id`) VALUES (CAST({:p0} AS jsonb), {:p1})", q.SQL())
+	assert.Equal(t, Params{"p0": "{}", "p1": 1}, q.Params())
+}
+
+func Test_structInfo_json(t *testing.T) {
+	type Profile struct {
+		Age  int    `json:"age"`
+		City string `json:"city"`
+	}
+	type User struct {
+		ID      int
+		Profile Profile           `db:"profile,json"`
+		Tags    []string          `db:"tags,json"`
+		Meta    map[string]string `db:"meta,json"`
+		Extra   *Profile          `db:"extra,json"`
+	}
+
+	si := getStructInfo(reflect.TypeOf(User{}), DefaultFieldMapFunc, nil)
+	assert.Len(t, si.nameMap, 5)
+	assert.Contains(t, si.dbNameMap, "profile")
+
+	user := User{ID: 1, Profile: Profile{30, "Paris"}, Tags: []string{"a"}}
+	sv := newStructValue(&user, DefaultFieldMapFunc, nil, GetTableName)
+	cols := sv.columns(nil, nil)
+	value, err := cols["profile"].(driver.Valuer).Value()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"age":30,"city":"Paris"}`, value)
+	value, _ = cols["tags"].(driver.Valuer).Value()
+	assert.Equal(t, `["a"]`, value)
+	value, _ = cols["meta"].(driver.Valuer).Value()
+	assert.Equal(t, `null`, value)
+	assert.Nil(t, cols["extra"])
+
+	user.Meta = map[string]string{"a": "1"}
+	s := si.nameMap["Meta"].scanTarget(sv.value, nil).(sql.Scanner)
+	assert.Nil(t, s.Scan([]byte(`{"b":"2"}`)))
+	assert.Equal(t, map[string]string{"b": "2"}, user.Meta)
+	assert.Nil(t, s.Scan(nil))
+	assert.Nil(t, user.Meta)
+	s = si.nameMap["Extra"].scanTarget(sv.value, nil).(sql.Scanner)
+	assert.Nil(t, s.Scan(`{"age":20}`))
+	assert.Equal(t, &Profile{Age: 20}, user.Extra)
+	assert.NotNil(t, s.Scan(1))
+	assert.NotNil(t, s.Scan("{"))
+	_, ok := si.nameMap["ID"].scanTarget(sv.value, nil).(*int)
+	assert.True(t, ok)
+}
+
 func TestIssue37(t *testing.T) {
 	customer := Customer{
 		ID:     1,
@@ -103,7 +211,7 @@ func TestIssue37(t *testing.T) {
 		Customer
 		Status string
 	}{customer, "20"}
-	sv := newStructValue(&ev, nil, GetTableName)
+	sv := newStructValue(&ev, nil, nil, GetTableName)
 	cols := sv.columns([]string{"ID", "Status"}, nil)
 	assert.Equal(t, map[string]interface{}{"ID": 1, "Status": "20"}, cols)
 
@@ -111,11 +219,87 @@ func TestIssue37(t *testing.T) {
 		Status string
 		Customer
 	}{"20", customer}
-	sv = newStructValue(&ev2, nil, GetTableName)
+	sv = newStructValue(&ev2, nil, nil, GetTableName)
 	cols = sv.columns([]string{"ID", "Status"}, nil)
 	assert.Equal(t, map[string]interface{}{"ID": 1, "Status": "20"}, cols)
 }
 
+func Test_structInfo_pkExp(t *testing.T) {
+	type OrderItem struct {
+		OrderID  int `db:"pk"`
+		ItemID   int `db:"pk"`
+		Quantity int
+	}
+	si := getStructInfo(reflect.TypeOf(OrderItem{}), DefaultFieldMapFunc, nil)
+	expected := HashExp{"order_id": 1, "item_id": 2}
+
+	exp, err := si.pkExp(OrderItem{OrderID: 1, ItemID: 2}, DefaultFieldMapFunc, nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, expected, exp)
+	}
+	exp, err = si.pkExp(&struct{ OrderID, ItemID int }{1, 2}, DefaultFieldMapFunc, nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, expected, exp)
+	}
+	exp, err = si.pkExp(map[string]interface{}{"OrderID": 1, "item_id": 2}, DefaultFieldMapFunc, nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, expected, exp)
+	}
+	exp, err = si.pkExp([]interface{}{1, 2}, DefaultFieldMapFunc, nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, expected, exp)
+	}
+	exp, err = si.pkExp([2]int{1, 2}, DefaultFieldMapFunc, nil)
+	if assert.Nil(t, err) {
+		assert.Equal(t, expected, exp)
+	}
+
+	_, err = si.pkExp(1, DefaultFieldMapFunc, nil)
+	assert.Equal(t, CompositePKError, err)
+	_, err = si.pkExp([]int{1}, DefaultFieldMapFunc, nil)
+	assert.Equal(t, CompositePKError, err)
+	_, err = si.pkExp(map[string]int{"order_id": 1}, DefaultFieldMapFunc, nil)
+	assert.Equal(t, CompositePKError, err)
+	_, err = si.pkExp(struct{ OrderID int }{1}, DefaultFieldMapFunc, nil)
+	assert.Equal(t, CompositePKError, err)
+}
+
+func Test_structInfo_softDeleteExp(t *testing.T) {
+	db := getDB()
+
+	type Post struct {
+		ID        int
+		DeletedAt *time.Time `db:"deleted_at,softDelete"`
+	}
+	si := getStructInfo(reflect.TypeOf(Post{}), DefaultFieldMapFunc, nil)
+	assert.Equal(t, "`post`.`deleted_at` IS NULL", si.softDeleteExp("post", false).Build(db, Params{}))
+	assert.Equal(t, "NOT (`deleted_at` IS NULL)", si.softDeleteExp("", true).Build(db, Params{}))
+
+	type Comment struct {
+		ID      int
+		Deleted int64 `db:"deleted,softDelete"`
+	}
+	si = getStructInfo(reflect.TypeOf(Comment{}), DefaultFieldMapFunc, nil)
+	params := Params{}
+	assert.Equal(t, "`comment`.`deleted`={:p0}", si.softDeleteExp("comment", false).Build(db, params))
+	assert.Equal(t, Params{"p0": 0}, params)
+
+	type Tag struct {
+		ID      int
+		Deleted time.Time `db:"deleted,softDelete"`
+	}
+	si = getStructInfo(reflect.TypeOf(Tag{}), DefaultFieldMapFunc, nil)
+	params = Params{}
+	assert.Equal(t, "`tag`.`deleted`={:p0}", si.softDeleteExp("tag", false).Build(db, params))
+	assert.Equal(t, Params{"p0": time.Time{}}, params)
+	params = Params{}
+	assert.Equal(t, "NOT (`deleted`={:p0})", si.softDeleteExp("", true).Build(db, params))
+	assert.Equal(t, Params{"p0": time.Time{}}, params)
+
+	si = getStructInfo(reflect.TypeOf(Customer{}), DefaultFieldMapFunc, nil)
+	assert.Nil(t, si.softDeleteExp("customer", false))
+}
+
 type MyCustomer struct{}
 
 func TestGetTableName(t *testing.T) {
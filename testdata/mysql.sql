@@ -12,6 +12,7 @@ DROP TABLE IF EXISTS `item` CASCADE;
 DROP TABLE IF EXISTS `order` CASCADE;
 DROP TABLE IF EXISTS `customer` CASCADE;
 DROP TABLE IF EXISTS `user` CASCADE;
+DROP TABLE IF EXISTS `post` CASCADE;
 
 CREATE TABLE `customer` (
   `id` int(11) NOT NULL AUTO_INCREMENT,
@@ -30,6 +31,14 @@ CREATE TABLE `user` (
   PRIMARY KEY (`id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8;
 
+CREATE TABLE `post` (
+  `id` int(11) NOT NULL,
+  `title` varchar(128) NOT NULL,
+  `status` int(11) NOT NULL DEFAULT 1,
+  `created` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
 CREATE TABLE `item` (
   `id` int(11) NOT NULL AUTO_INCREMENT,
   `name` varchar(128) NOT NULL,